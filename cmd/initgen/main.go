@@ -1,17 +1,29 @@
-// package initgen generates initializers for provider functions registered in the current working dir package.
+// package initgen generates an injector for the provider functions registered in a package.
+// The registrations are type checked, no code of the package is run.
+//
+// Usage:
+//
+//	initgen [flags] [packages]                                    generate init.go
+//	initgen [flags] -check [packages]                             report whether init.go is up to date
+//	initgen [flags] graph [-format dot|mermaid|json] [packages]   print the dependency graph
+//
+// Packages are import paths or patterns like ./... and default to the current working dir package.
+// Packages without the source file or a di.Generate call in it are skipped.
 package main
 
 import (
 	"bytes"
+	"errors"
+	"flag"
 	"fmt"
-	"io/ioutil"
+	"io/fs"
 	"log"
 	"os"
-	"os/exec"
-	"path"
-	"path/filepath"
+	"strings"
 
-	"github.com/moznion/gowrtr/generator"
+	"github.com/mgnsk/di-container/di"
+	"github.com/mgnsk/di-container/internal/diff"
+	"github.com/mgnsk/di-container/internal/initgen"
 )
 
 func check(err error) {
@@ -20,54 +32,73 @@ func check(err error) {
 	}
 }
 
-func getCurrentPkg() string {
-	pkgImport, err := exec.Command("go", "list", "-f", "{{.ImportPath}}", ".").Output()
-	check(err)
+func main() {
+	var cfg initgen.Config
 
-	return string(bytes.TrimSpace(pkgImport))
-}
+	checkOnly := flag.Bool("check", false, "print a diff and exit with status 1 if the generated files are not up to date, without writing them")
+	flag.StringVar(&cfg.Source, "source", "initgen.go", "name of the file with the di.Generate call")
+	flag.StringVar(&cfg.Output, "output", "init.go", "path of the generated file relative to the package dir, an absolute path requires a single package")
+	pkg := flag.String("pkg", "", "package to generate for, in addition to the package arguments")
+	tags := flag.String("tags", "", "comma separated list of build tags")
+	flag.Parse()
 
-func main() {
-	cwd, err := os.Getwd()
-	check(err)
+	if *tags != "" {
+		cfg.Tags = strings.Split(*tags, ",")
+	}
 
-	source := filepath.Join(cwd, "initgen.go")
-	target := filepath.Join(cwd, "init.go")
-	tmpDir := filepath.Join(cwd, "initgen")
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "graph" {
+		flags := flag.NewFlagSet("graph", flag.ExitOnError)
+		name := flags.String("format", di.FormatDOT.String(), "output format: dot, mermaid or json")
+		check(flags.Parse(args[1:]))
 
-	_, err = os.Stat(source)
-	check(err)
+		format, err := di.ParseFormat(*name)
+		check(err)
+
+		check(cfg.WriteGraph(os.Stdout, format, patterns(flags.Args(), *pkg)...))
+		return
+	}
 
-	check(os.RemoveAll(tmpDir))
-	check(os.RemoveAll(target))
-	check(os.Mkdir(tmpDir, 0o755))
-	defer os.RemoveAll(tmpDir)
-
-	fmt.Printf("initgen: generating %s\n", target)
-
-	pkg := getCurrentPkg()
-	g := generator.
-		NewRoot(
-			generator.NewPackage("main"),
-			generator.NewNewline(),
-			generator.NewImport(pkg),
-			generator.NewNewline(),
-		).
-		AddStatements(
-			generator.NewFunc(
-				nil,
-				generator.NewFuncSignature("main"),
-			).AddStatements(generator.NewRawStatement(path.Base(pkg) + ".Generate()")),
-		)
-
-	generated, err := g.Generate(0)
+	files, err := cfg.Generate(patterns(args, *pkg)...)
 	check(err)
 
-	mainFile := filepath.Join(tmpDir, "main.go")
-	check(ioutil.WriteFile(mainFile, []byte(generated), 0o644))
+	if *checkOnly {
+		if !upToDate(files) {
+			os.Exit(1)
+		}
+		return
+	}
 
-	// Run the container generator.
-	res, err := exec.Command("go", "run", mainFile).CombinedOutput()
-	fmt.Printf(string(res))
-	check(err)
+	for _, f := range files {
+		fmt.Printf("initgen: generating %s\n", f.Path)
+		check(os.WriteFile(f.Path, f.Src, 0o644))
+	}
+}
+
+// patterns returns the package arguments and the -pkg package.
+func patterns(args []string, pkg string) []string {
+	if pkg != "" {
+		return append(args, pkg)
+	}
+	return args
+}
+
+// upToDate reports whether the generated files equal the files on disk.
+// Otherwise the diff to the generated code is printed.
+func upToDate(files []initgen.File) bool {
+	ok := true
+
+	for _, f := range files {
+		current, err := os.ReadFile(f.Path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			check(err)
+		}
+
+		if !bytes.Equal(current, f.Src) {
+			fmt.Print(diff.Unified(f.Path, f.Path+" (generated)", current, f.Src))
+			ok = false
+		}
+	}
+
+	return ok
 }
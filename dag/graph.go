@@ -0,0 +1,359 @@
+// Package dag implements a generic directed acyclic graph.
+//
+// An edge from a node to another node means that the node depends
+// on the other node. Nodes and edges are kept in insertion order,
+// so all queries return deterministic results.
+package dag
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Graph is a directed acyclic graph of values of type V keyed by K.
+// A Graph is not safe for concurrent modification.
+type Graph[K comparable, V any] struct {
+	keys       []K
+	values     map[K]V
+	edges      map[K][]K
+	edgeSet    map[K]map[K]struct{}
+	dependents map[K][]K
+}
+
+// New creates an empty graph.
+func New[K comparable, V any]() *Graph[K, V] {
+	return &Graph[K, V]{
+		values:     make(map[K]V),
+		edges:      make(map[K][]K),
+		edgeSet:    make(map[K]map[K]struct{}),
+		dependents: make(map[K][]K),
+	}
+}
+
+// CycleError is returned when the graph contains a cycle.
+type CycleError[K comparable] struct {
+	// Path of the cycle in edge order. The first key
+	// is repeated at the end: A -> B -> C -> A.
+	Path []K
+}
+
+func (e *CycleError[K]) Error() string {
+	path := make([]string, len(e.Path))
+	for i, k := range e.Path {
+		path[i] = fmt.Sprint(k)
+	}
+	return fmt.Sprintf("cycle detected: %s", strings.Join(path, " -> "))
+}
+
+// Add adds a node or replaces the value of an existing node.
+func (g *Graph[K, V]) Add(k K, v V) {
+	if _, ok := g.values[k]; !ok {
+		g.keys = append(g.keys, k)
+	}
+	g.values[k] = v
+}
+
+// Has reports whether the graph contains the node k.
+func (g *Graph[K, V]) Has(k K) bool {
+	_, ok := g.values[k]
+	return ok
+}
+
+// Value returns the value of the node k.
+func (g *Graph[K, V]) Value(k K) (V, bool) {
+	v, ok := g.values[k]
+	return v, ok
+}
+
+// Len returns the number of nodes.
+func (g *Graph[K, V]) Len() int {
+	return len(g.keys)
+}
+
+// Keys returns the keys of the nodes in insertion order.
+func (g *Graph[K, V]) Keys() []K {
+	return append([]K(nil), g.keys...)
+}
+
+// AddEdge adds an edge from the node from to the node to
+// unless it already exists. Both nodes must be in the graph.
+func (g *Graph[K, V]) AddEdge(from, to K) {
+	if !g.Has(from) || !g.Has(to) {
+		panic(fmt.Errorf("dag: edge from '%v' to '%v' between unknown nodes", from, to))
+	}
+	if _, ok := g.edgeSet[from][to]; ok {
+		return
+	}
+	if g.edgeSet[from] == nil {
+		g.edgeSet[from] = make(map[K]struct{})
+	}
+	g.edgeSet[from][to] = struct{}{}
+	g.edges[from] = append(g.edges[from], to)
+	g.dependents[to] = append(g.dependents[to], from)
+}
+
+// HasEdge reports whether the graph contains an edge from the node from to the node to.
+func (g *Graph[K, V]) HasEdge(from, to K) bool {
+	_, ok := g.edgeSet[from][to]
+	return ok
+}
+
+// RemoveEdges removes the outgoing edges of the node k.
+func (g *Graph[K, V]) RemoveEdges(k K) {
+	for _, to := range g.edges[k] {
+		deps := g.dependents[to]
+		for i, from := range deps {
+			if from == k {
+				g.dependents[to] = append(deps[:i:i], deps[i+1:]...)
+				break
+			}
+		}
+	}
+	delete(g.edges, k)
+	delete(g.edgeSet, k)
+}
+
+// Edges returns the nodes the node k has an edge to.
+func (g *Graph[K, V]) Edges(k K) []K {
+	return append([]K(nil), g.edges[k]...)
+}
+
+// Dependents returns the nodes that have an edge to the node k.
+func (g *Graph[K, V]) Dependents(k K) []K {
+	return append([]K(nil), g.dependents[k]...)
+}
+
+// OutDegrees returns the number of outgoing edges of each node,
+// i.e. the number of nodes each node depends on.
+func (g *Graph[K, V]) OutDegrees() map[K]int {
+	out := make(map[K]int, len(g.keys))
+	for _, k := range g.keys {
+		out[k] = len(g.edges[k])
+	}
+	return out
+}
+
+// visit state of a node during a traversal.
+type visit int
+
+const (
+	unvisited visit = iota
+	current
+	visited
+)
+
+// Sort returns the keys in topological order so that each node comes
+// after the nodes its edges point to. Ties are broken by insertion order.
+// Sort runs in O(V+E) and does not modify the graph.
+func (g *Graph[K, V]) Sort() ([]K, error) {
+	state := make(map[K]visit, len(g.keys))
+	sorted := make([]K, 0, len(g.keys))
+
+	// path holds the nodes currently being visited.
+	var path []K
+
+	var visitNode func(k K) error
+	visitNode = func(k K) error {
+		switch state[k] {
+		case visited:
+			return nil
+		case current:
+			// The cycle starts at the previous visit of k.
+			for i := len(path) - 1; i >= 0; i-- {
+				if path[i] == k {
+					cycle := append(append([]K(nil), path[i:]...), k)
+					return &CycleError[K]{Path: cycle}
+				}
+			}
+		}
+
+		state[k] = current
+		path = append(path, k)
+		for _, edge := range g.edges[k] {
+			if err := visitNode(edge); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[k] = visited
+
+		sorted = append(sorted, k)
+
+		return nil
+	}
+
+	for _, k := range g.keys {
+		if err := visitNode(k); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+// Levels partitions the nodes into levels using Kahn's algorithm such that
+// the edges of each node point to nodes in earlier levels. Nodes in the
+// same level do not depend on each other and can be processed in parallel.
+// Levels runs in O(V+E) apart from ordering the nodes of each level.
+func (g *Graph[K, V]) Levels() ([][]K, error) {
+	// The number of dependencies of each node not yet in a level.
+	pending := g.OutDegrees()
+
+	pos := make(map[K]int, len(g.keys))
+	for i, k := range g.keys {
+		pos[k] = i
+	}
+
+	var current []K
+	for _, k := range g.keys {
+		if pending[k] == 0 {
+			current = append(current, k)
+		}
+	}
+
+	var levels [][]K
+	n := 0
+	for len(current) > 0 {
+		levels = append(levels, current)
+		n += len(current)
+
+		var next []K
+		for _, k := range current {
+			for _, d := range g.dependents[k] {
+				pending[d]--
+				if pending[d] == 0 {
+					next = append(next, d)
+				}
+			}
+		}
+		// Keep the nodes of a level in insertion order.
+		sort.Slice(next, func(i, j int) bool {
+			return pos[next[i]] < pos[next[j]]
+		})
+		current = next
+	}
+
+	if n != len(g.keys) {
+		// Report the cycle with its path.
+		if _, err := g.Sort(); err != nil {
+			return nil, err
+		}
+	}
+
+	return levels, nil
+}
+
+// Descendants returns the nodes reachable from the node k,
+// i.e. its transitive dependencies, in breadth-first order.
+func (g *Graph[K, V]) Descendants(k K) []K {
+	return g.reach(g.edges, k)
+}
+
+// Ancestors returns the nodes from which the node k is reachable,
+// i.e. its transitive dependents, in breadth-first order.
+func (g *Graph[K, V]) Ancestors(k K) []K {
+	return g.reach(g.dependents, k)
+}
+
+// reach returns the nodes reachable from k along adj, excluding k.
+func (g *Graph[K, V]) reach(adj map[K][]K, k K) []K {
+	seen := map[K]bool{k: true}
+	queue := []K{k}
+
+	var result []K
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		for _, m := range adj[n] {
+			if !seen[m] {
+				seen[m] = true
+				result = append(result, m)
+				queue = append(queue, m)
+			}
+		}
+	}
+
+	return result
+}
+
+// Subgraph returns a new graph of the roots and their descendants
+// with the edges between them, in the insertion order of g.
+func (g *Graph[K, V]) Subgraph(roots ...K) *Graph[K, V] {
+	include := make(map[K]bool)
+	for _, root := range roots {
+		if !g.Has(root) {
+			continue
+		}
+		include[root] = true
+		for _, k := range g.Descendants(root) {
+			include[k] = true
+		}
+	}
+
+	sub := New[K, V]()
+	for _, k := range g.keys {
+		if include[k] {
+			sub.Add(k, g.values[k])
+		}
+	}
+	for _, k := range sub.keys {
+		for _, edge := range g.edges[k] {
+			sub.AddEdge(k, edge)
+		}
+	}
+
+	return sub
+}
+
+// TransitiveReduction returns a new graph with the same nodes and
+// reachability but without the edges implied by other paths.
+// The graph must be acyclic.
+func (g *Graph[K, V]) TransitiveReduction() (*Graph[K, V], error) {
+	sorted, err := g.Sort()
+	if err != nil {
+		return nil, err
+	}
+
+	// Position of each node in the topological order.
+	pos := make(map[K]int, len(sorted))
+	for i, k := range sorted {
+		pos[k] = i
+	}
+
+	reduced := New[K, V]()
+	for _, k := range g.keys {
+		reduced.Add(k, g.values[k])
+	}
+
+	for _, k := range g.keys {
+		// An edge k -> e is redundant if e is reachable through
+		// another edge of k. Edges are checked from the nearest
+		// dependency in topological order.
+		edges := append([]K(nil), g.edges[k]...)
+		sort.Slice(edges, func(i, j int) bool {
+			return pos[edges[i]] > pos[edges[j]]
+		})
+
+		reachable := make(map[K]bool)
+		keep := make(map[K]bool)
+		for _, e := range edges {
+			if reachable[e] {
+				continue
+			}
+			keep[e] = true
+			for _, d := range g.Descendants(e) {
+				reachable[d] = true
+			}
+		}
+
+		// Keep the edges in insertion order.
+		for _, e := range g.edges[k] {
+			if keep[e] {
+				reduced.AddEdge(k, e)
+			}
+		}
+	}
+
+	return reduced, nil
+}
@@ -0,0 +1,143 @@
+package dag
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// newGraph creates a graph of the keys with edges from each key to its dependencies.
+func newGraph(keys []string, edges map[string][]string) *Graph[string, int] {
+	g := New[string, int]()
+	for i, k := range keys {
+		g.Add(k, i)
+	}
+	for _, k := range keys {
+		for _, e := range edges[k] {
+			g.AddEdge(k, e)
+		}
+	}
+	return g
+}
+
+func assertKeys(t *testing.T, expected, got []string) {
+	t.Helper()
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestSort(t *testing.T) {
+	g := newGraph([]string{"app", "db", "config", "cache"}, map[string][]string{
+		"app":   {"db", "cache"},
+		"db":    {"config"},
+		"cache": {"config"},
+	})
+
+	sorted, err := g.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertKeys(t, []string{"config", "db", "cache", "app"}, sorted)
+
+	// Sorting is repeatable and nodes can be added after sorting.
+	g.Add("log", 4)
+	g.AddEdge("app", "log")
+	g.AddEdge("app", "log")
+
+	sorted, err = g.Sort()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertKeys(t, []string{"config", "db", "cache", "log", "app"}, sorted)
+	assertKeys(t, []string{"db", "cache", "log"}, g.Edges("app"))
+
+	g.RemoveEdges("app")
+	assertKeys(t, nil, g.Edges("app"))
+	assertKeys(t, nil, g.Dependents("db"))
+}
+
+func TestSortCycle(t *testing.T) {
+	g := newGraph([]string{"a", "b", "c", "d"}, map[string][]string{
+		"a": {"b"},
+		"b": {"c"},
+		"c": {"d", "b"},
+	})
+
+	_, err := g.Sort()
+
+	var cycle *CycleError[string]
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected CycleError, got %v", err)
+	}
+	assertKeys(t, []string{"b", "c", "b"}, cycle.Path)
+
+	if err.Error() != "cycle detected: b -> c -> b" {
+		t.Fatalf("unexpected error message '%s'", err)
+	}
+
+	if _, err := g.Levels(); !errors.As(err, &cycle) {
+		t.Fatalf("expected CycleError, got %v", err)
+	}
+}
+
+func TestLevels(t *testing.T) {
+	g := newGraph([]string{"app", "db", "config", "cache", "log"}, map[string][]string{
+		"app":   {"db", "cache"},
+		"db":    {"config", "log"},
+		"cache": {"config"},
+	})
+
+	levels, err := g.Levels()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{{"config", "log"}, {"db", "cache"}, {"app"}}
+	if !reflect.DeepEqual(expected, levels) {
+		t.Fatalf("expected %v, got %v", expected, levels)
+	}
+
+	out := g.OutDegrees()
+	if out["app"] != 2 || out["config"] != 0 {
+		t.Fatalf("unexpected out-degrees %v", out)
+	}
+}
+
+func TestReachability(t *testing.T) {
+	g := newGraph([]string{"app", "db", "config", "cache", "log"}, map[string][]string{
+		"app":   {"db", "cache"},
+		"db":    {"config"},
+		"cache": {"config"},
+	})
+
+	assertKeys(t, []string{"db", "cache", "config"}, g.Descendants("app"))
+	assertKeys(t, []string{"db", "cache", "app"}, g.Ancestors("config"))
+	assertKeys(t, nil, g.Ancestors("log"))
+
+	sub := g.Subgraph("db", "log")
+	assertKeys(t, []string{"db", "config", "log"}, sub.Keys())
+	assertKeys(t, []string{"config"}, sub.Edges("db"))
+
+	if v, ok := sub.Value("config"); !ok || v != 2 {
+		t.Fatalf("expected value 2, got %d", v)
+	}
+}
+
+func TestTransitiveReduction(t *testing.T) {
+	g := newGraph([]string{"app", "db", "config"}, map[string][]string{
+		"app": {"config", "db"},
+		"db":  {"config"},
+	})
+
+	reduced, err := g.TransitiveReduction()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertKeys(t, []string{"db"}, reduced.Edges("app"))
+	assertKeys(t, []string{"config"}, reduced.Edges("db"))
+
+	// The original graph is not modified.
+	assertKeys(t, []string{"config", "db"}, g.Edges("app"))
+}
@@ -1,136 +1,785 @@
 package di
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"sync"
 	"sync/atomic"
 
-	"github.com/mgnsk/di-container/internal/dag"
+	"github.com/mgnsk/di-container/dag"
 )
 
 // An Item is a container item.
 type Item struct {
 	Value interface{}
 
+	// mu guards Value, built and cleanup and ensures
+	// that a singleton is constructed only once.
+	mu sync.Mutex
+
 	provider reflect.Value
-	node     *dag.Node
 	index    uint64
+	built    bool
+	key      key
+	// resolved reports whether the dependencies of the item are resolved.
+	resolved bool
+	group    string
+	params   []param
+	onStart  []hook
+	onClose  []hook
+	cleanup  func()
+	lifetime lifetime
+
+	returnsCleanup bool
+	returnsErr     bool
+
+	// field is the name of the field of a result struct
+	// embedding Out that provides the item.
+	field string
+
+	// owner is the container the item is registered in.
+	owner *Container
+}
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	cleanupType = reflect.TypeOf((func())(nil))
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// key identifies an item by type and an optional name.
+type key struct {
+	typ  reflect.Type
+	name string
+}
+
+func (k key) String() string {
+	if k.name == "" {
+		return k.typ.String()
+	}
+	return fmt.Sprintf("%s name=%q", k.typ, k.name)
+}
+
+// binding binds an interface type to a registered concrete type.
+type binding struct {
+	iface    reflect.Type
+	concrete reflect.Type
 }
 
 // Container is a generic dependency container.
+//
+// Register, RegisterNamed, RegisterValue and Bind may be called concurrently.
+// After Resolve, Get, Invoke and the generic getters are safe for concurrent use
+// and each singleton provider is called at most once, with concurrent requests
+// for the same item waiting for its construction. Resolve, Build, Start and Close
+// must not be called concurrently with each other.
 type Container struct {
-	items map[reflect.Type]*Item
-	deps  dag.Graph
-	index uint64
+	// mu guards the registration state, the resolved flags of the items
+	// and the closed flag.
+	// It is never held while a provider is called.
+	mu        sync.RWMutex
+	resolveMu sync.Mutex
+
+	items    map[key]*Item
+	aliases  map[key]*Item
+	groups   map[string][]*Item
+	bindings []binding
+	deps     *dag.Graph[*Item, struct{}]
+	// order of the items, sorted by dependencies after Resolve
+	// and followed by the items registered since.
+	order  []*Item
+	parent *Container
+	closed bool
+	index  uint64
 }
 
 // NewContainer creates an empty container.
 func NewContainer() *Container {
 	return &Container{
-		items: make(map[reflect.Type]*Item),
+		items:   make(map[key]*Item),
+		aliases: make(map[key]*Item),
+		groups:  make(map[string][]*Item),
+		deps:    dag.New[*Item, struct{}](),
 	}
 }
 
 // Register registers a provider function for a type.
-func (c *Container) Register(provider interface{}) {
+// The provider returns the provided value, optionally followed by
+// a cleanup function of type func() and an error, in this order.
+func (c *Container) Register(provider interface{}, opts ...Option) {
+	c.register("", provider, opts)
+}
+
+// RegisterNamed registers a provider function for a type under a name.
+// Multiple providers of the same type can be registered with distinct names.
+func (c *Container) RegisterNamed(name string, provider interface{}, opts ...Option) {
+	if name == "" {
+		panic("container: name must not be empty")
+	}
+	c.register(name, provider, opts)
+}
+
+// register a provider. Keep in sync with loader.register in internal/initgen.
+func (c *Container) register(name string, provider interface{}, opts []Option) {
 	providerType := reflect.TypeOf(provider)
 	if providerType.Kind() != reflect.Func {
 		panic("container: provider must be a function")
 	}
-	if providerType.NumOut() == 0 || providerType.NumOut() > 2 {
-		panic("container: provider must return at least 1 value and not more than 2")
+	if providerType.NumOut() == 0 || providerType.NumOut() > 3 {
+		panic("container: provider must return at least 1 value and not more than 3")
 	}
 
-	typ := providerType.Out(0)
-	if _, ok := c.items[typ]; ok {
-		panic(fmt.Errorf("container: item type '%T' is already registered", typ))
+	o := newOptions(opts)
+	if name != "" && o.group != "" {
+		panic("container: provider cannot be both named and grouped")
 	}
 
-	// If the function returns 2 values, the second must be an error.
-	if providerType.NumOut() == 2 {
-		errorInterface := reflect.TypeOf((*error)(nil)).Elem()
-		if !providerType.Out(1).Implements(errorInterface) {
-			panic(fmt.Errorf("container: the type '%s' of the second return value of provider must be an error", providerType.Out(1)))
+	k := key{typ: providerType.Out(0), name: name}
+
+	// The value may be followed by a cleanup function and an error, in this order.
+	var returnsCleanup, returnsErr bool
+	switch providerType.NumOut() {
+	case 2:
+		returnsCleanup = providerType.Out(1) == cleanupType
+		returnsErr = providerType.Out(1).Implements(errorType)
+		if !returnsCleanup && !returnsErr {
+			panic(fmt.Errorf("container: the type '%s' of the second return value of provider must be an error or func()", providerType.Out(1)))
+		}
+	case 3:
+		returnsCleanup = true
+		returnsErr = true
+		if providerType.Out(1) != cleanupType {
+			panic(fmt.Errorf("container: the type '%s' of the second return value of provider must be func()", providerType.Out(1)))
 		}
+		if !providerType.Out(2).Implements(errorType) {
+			panic(fmt.Errorf("container: the type '%s' of the third return value of provider must be an error", providerType.Out(2)))
+		}
+	}
+
+	if o.group != "" && embeds(k.typ, outType) {
+		panic(fmt.Errorf("container: result struct '%s' cannot be grouped", k.typ))
 	}
 
+	onStart, onClose := o.hooks(k.typ)
+
+	if o.lifetime == transient && (returnsCleanup || len(onStart) > 0 || len(onClose) > 0) {
+		panic(fmt.Errorf("container: transient item type '%s' cannot have a cleanup function or lifecycle hooks", k))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	index := atomic.AddUint64(&c.index, 1)
 
 	item := &Item{
 		provider: reflect.ValueOf(provider),
-		node:     &dag.Node{},
 		index:    index - 1,
+		key:      k,
+		group:    o.group,
+		params:   o.params(providerType),
+		onStart:  onStart,
+		onClose:  onClose,
+		lifetime: o.lifetime,
+
+		returnsCleanup: returnsCleanup,
+		returnsErr:     returnsErr,
+	}
+
+	c.add(item)
+
+	if embeds(k.typ, outType) {
+		c.registerOut(item)
+	}
+}
+
+// add an item to the container. c.mu must be held.
+// Keep in sync with container.add in internal/initgen.
+func (c *Container) add(item *Item) {
+	if _, ok := c.items[item.key]; ok && item.group == "" {
+		panic(fmt.Errorf("container: item type '%s' is already registered", item.key))
 	}
 
-	item.node.Value = item
-	c.items[typ] = item
-	c.deps = append(c.deps, item.node)
+	item.owner = c
+	if item.group != "" {
+		c.groups[item.group] = append(c.groups[item.group], item)
+	} else {
+		c.items[item.key] = item
+	}
+	c.deps.Add(item, struct{}{})
+	c.order = append(c.order, item)
+}
+
+// Bind binds an interface type to a registered concrete type.
+// The concrete item is then resolved under both types.
+// Both types must be passed as nil pointers, e.g.
+// c.Bind((*greeter)(nil), (*mygreeter)(nil)).
+func (c *Container) Bind(iface, concrete interface{}) {
+	// Keep in sync with loader.bind in internal/initgen.
+	ifaceType := reflectType(iface)
+	if ifaceType.Kind() != reflect.Interface {
+		panic(fmt.Errorf("container: bound type '%s' must be an interface", ifaceType))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.bindings = append(c.bindings, binding{
+		iface:    ifaceType,
+		concrete: reflectType(concrete),
+	})
+}
+
+// lookup returns the item registered or bound for a key in the scope chain.
+func (c *Container) lookup(k key) (*Item, bool) {
+	c.mu.RLock()
+	item, ok := c.items[k]
+	if !ok {
+		item, ok = c.aliases[k]
+	}
+	c.mu.RUnlock()
+
+	if ok {
+		return item, true
+	}
+	if c.parent != nil {
+		return c.parent.lookup(k)
+	}
+	return nil, false
+}
+
+// ordered returns a snapshot of the items in dependency order.
+func (c *Container) ordered() []*Item {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return append([]*Item(nil), c.order...)
+}
+
+// resolveBindings aliases the bound interfaces to their items.
+// Keep in sync with container.resolveBindings in internal/initgen.
+func (c *Container) resolveBindings() error {
+	c.mu.RLock()
+	bindings := c.bindings[:len(c.bindings):len(c.bindings)]
+	c.mu.RUnlock()
+
+	aliases := make(map[key]*Item)
+
+	for _, b := range bindings {
+		c.mu.RLock()
+		_, provided := c.items[key{typ: b.iface}]
+		item, ok := c.items[key{typ: b.concrete}]
+		c.mu.RUnlock()
+
+		if provided {
+			return fmt.Errorf("Ambiguous binding for type '%s': type already has a provider", b.iface)
+		}
+
+		if !ok && c.parent != nil {
+			item, ok = c.parent.lookup(key{typ: b.concrete})
+		}
+		if !ok {
+			return &MissingProviderError{Type: b.concrete, RequiredBy: b.iface}
+		}
+
+		if !b.concrete.Implements(b.iface) {
+			return fmt.Errorf("Type '%s' does not implement '%s'", b.concrete, b.iface)
+		}
+
+		if alias, ok := aliases[key{typ: b.iface}]; ok && alias != item {
+			return fmt.Errorf("Ambiguous binding for type '%s': bound to both '%s' and '%s'", b.iface, alias.key, b.concrete)
+		}
+
+		aliases[key{typ: b.iface}] = item
+	}
+
+	c.mu.Lock()
+	c.aliases = aliases
+	c.mu.Unlock()
+
+	return nil
 }
 
 // Resolve the container.
 func (c *Container) Resolve() error {
-	for _, item := range c.items {
-		providerType := item.provider.Type()
-		// Range through provider arguments (dependencies of the node).
-		for i := 0; i < providerType.NumIn(); i++ {
-			if depItem, ok := c.items[providerType.In(i)]; ok {
-				// An item with this type was already registered, add it as an edge.
-				item.node.Edges = append(item.node.Edges, depItem.node)
-			} else {
-				return fmt.Errorf("Missing provider for type '%s'", providerType.In(i))
+	// Keep in sync with container.resolve in internal/initgen.
+	c.resolveMu.Lock()
+	defer c.resolveMu.Unlock()
+
+	if err := c.resolveBindings(); err != nil {
+		return err
+	}
+
+	// Resolve may be called again after registering more items.
+	// The edges are recreated and the items built so far are kept.
+	items := c.ordered()
+
+	// All missing dependencies are reported at once in registration order.
+	var errs []error
+	edges := make(map[*Item][]*Item, len(items))
+	for _, item := range items {
+		// Range through provider arguments (dependencies of the item).
+		for _, param := range item.params {
+			depItems, err := c.resolveParam(item.key.typ, param)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, depItem := range depItems {
+				// Items of parent containers are already resolved.
+				if c.owns(depItem) {
+					edges[item] = append(edges[item], depItem)
+				}
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if err := c.validateLifetimes(); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, item := range items {
+		c.deps.RemoveEdges(item)
+		for _, depItem := range edges[item] {
+			c.deps.AddEdge(item, depItem)
+		}
+	}
+
+	order, err := c.deps.Sort()
+	if err != nil {
+		var cycle *dag.CycleError[*Item]
+		if errors.As(err, &cycle) {
+			return newCycleError(cycle.Path)
+		}
+		return err
+	}
+
+	c.order = order
+	for _, item := range items {
+		item.resolved = true
+	}
+
+	return nil
+}
+
+// resolveParam returns the items a parameter of requiredBy depends on.
+// Keep in sync with container.resolveParam in internal/initgen.
+func (c *Container) resolveParam(requiredBy reflect.Type, p param) ([]*Item, error) {
+	if p.ctx {
+		return nil, nil
+	}
+
+	if p.in {
+		var items []*Item
+		var errs []error
+		for _, f := range p.fields {
+			fieldItems, err := c.resolveParam(requiredBy, f)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			items = append(items, fieldItems...)
+		}
+		return items, errors.Join(errs...)
+	}
+
+	if p.group != "" {
+		members, _ := c.groupMembers(p.group)
+		elem := p.key.typ.Elem()
+		for _, member := range members {
+			if !member.key.typ.AssignableTo(elem) {
+				return nil, fmt.Errorf("Type '%s' of group '%s' is not assignable to '%s'", member.key.typ, p.group, elem)
+			}
+		}
+		return members, nil
+	}
+
+	if item, _, ok := c.lookupParam(p); ok {
+		return []*Item{item}, nil
+	}
+
+	if p.optional {
+		return nil, nil
+	}
+
+	return nil, &MissingProviderError{Type: p.key.typ, Name: p.key.name, RequiredBy: requiredBy}
+}
+
+// argValue returns the value of a provider parameter.
+func (c *Container) argValue(ctx context.Context, p param) (reflect.Value, error) {
+	switch {
+	case p.ctx:
+		return reflect.ValueOf(&ctx).Elem(), nil
+
+	case p.in:
+		v := reflect.New(p.key.typ).Elem()
+		for _, f := range p.fields {
+			fv, err := c.argValue(ctx, f)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			v.Field(f.field).Set(fv)
+		}
+		return v, nil
+
+	case p.group != "":
+		// Assemble the group slice in registration order.
+		members, _ := c.groupMembers(p.group)
+		elem := p.key.typ.Elem()
+		slice := reflect.MakeSlice(p.key.typ, 0, len(members))
+		for _, member := range members {
+			mv, err := c.itemValue(ctx, member, elem)
+			if err != nil {
+				return reflect.Value{}, err
+			}
+			slice = reflect.Append(slice, mv)
+		}
+		return slice, nil
+
+	default:
+		item, factory, ok := c.lookupParam(p)
+		if factory {
+			return c.factoryValue(p.key.typ, item), nil
+		}
+
+		var value reflect.Value
+		if ok {
+			var err error
+			if value, err = c.itemValue(ctx, item, p.key.typ); err != nil {
+				return reflect.Value{}, err
 			}
 		}
+
+		if p.wrapped != nil {
+			return optionalValue(p.wrapped, value, ok), nil
+		}
+		if !ok {
+			// A missing optional dependency.
+			return reflect.Zero(p.key.typ), nil
+		}
+		return value, nil
+	}
+}
+
+// itemValue returns the value of an item as typ.
+// A new instance is created for transient items
+// and singletons are built on first access.
+func (c *Container) itemValue(ctx context.Context, item *Item, typ reflect.Type) (reflect.Value, error) {
+	// Dependencies of the item are resolved in the container it is registered in.
+	if item.lifetime == transient {
+		if !item.owner.ready(item) {
+			return reflect.Value{}, fmt.Errorf("container: type '%s': %w", item.key, ErrNotBuilt)
+		}
+		value, _, err := item.owner.call(ctx, item)
+		return value, err
+	}
+	value, err := item.owner.build(ctx, item)
+	if err != nil {
+		return reflect.Value{}, err
+	}
+	return valueOf(value, typ), nil
+}
+
+// build a singleton item and its dependencies unless already built
+// and return its value. Concurrent callers wait for the construction.
+func (c *Container) build(ctx context.Context, item *Item) (interface{}, error) {
+	item.mu.Lock()
+	defer item.mu.Unlock()
+
+	if item.built {
+		return item.Value, nil
+	}
+
+	if !c.ready(item) {
+		return nil, fmt.Errorf("container: type '%s': %w", item.key, ErrNotBuilt)
 	}
-	return c.deps.Resolve()
+
+	value, cleanup, err := c.call(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+
+	item.Value = value.Interface()
+	item.cleanup = cleanup
+	item.built = true
+
+	return item.Value, nil
+}
+
+// ready reports whether an item of the container is resolved
+// and the container is not closed.
+func (c *Container) ready(item *Item) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return item.resolved && !c.closed
+}
+
+// call the provider of an item with its dependencies.
+func (c *Container) call(ctx context.Context, item *Item) (value reflect.Value, cleanup func(), err error) {
+	// Building is aborted between providers when the context is done.
+	if err := ctx.Err(); err != nil {
+		return reflect.Value{}, nil, newProviderError(item, err)
+	}
+
+	// Populate the dependencies (arguments) of the item provider function.
+	args := make([]reflect.Value, 0, len(item.params))
+	for _, param := range item.params {
+		arg, err := c.argValue(ctx, param)
+		if err != nil {
+			var pe *ProviderError
+			if errors.As(err, &pe) {
+				pe.Path = append([]reflect.Type{item.key.typ}, pe.Path...)
+				return reflect.Value{}, nil, pe
+			}
+			return reflect.Value{}, nil, err
+		}
+		args = append(args, arg)
+	}
+
+	result := item.provider.Call(args)
+	if item.returnsErr && !result[len(result)-1].IsNil() {
+		// The error is always the last value.
+		err := result[len(result)-1].Interface().(error)
+		return reflect.Value{}, nil, newProviderError(item, err)
+	}
+	if !result[0].IsValid() {
+		panic("invalid value")
+	}
+	if item.returnsCleanup {
+		cleanup = result[1].Interface().(func())
+	}
+
+	return result[0], cleanup, nil
 }
 
 // Range over the container items in dependency order.
 func (c *Container) Range(f func(item *Item) bool) {
-	for _, item := range c.deps {
-		if f(item.Value.(*Item)) == false {
+	for _, item := range c.ordered() {
+		if f(item) == false {
 			break
 		}
 	}
 }
 
-// Build the container.
-func (c *Container) Build() error {
-	for _, item := range c.deps {
-		// Populate the dependencies (arguments) of the item provider function.
-		var args []reflect.Value
-		item := item.Value.(*Item)
-		providerType := item.provider.Type()
+// Build all singleton items of the resolved container.
+// Calling Build is optional, items are otherwise built
+// on first access together with their dependencies.
+// If a provider fails, the items built so far are closed
+// and the close errors are joined with the provider error.
+func (c *Container) Build(opts ...BuildOption) error {
+	return c.BuildContext(context.Background(), opts...)
+}
+
+// BuildContext builds all singleton items like Build. Provider parameters
+// of type context.Context receive ctx and building is aborted between
+// providers when ctx is done.
+func (c *Container) BuildContext(ctx context.Context, opts ...BuildOption) error {
+	var o buildOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	c.setClosed(false)
+
+	var err error
+	if o.workers > 0 {
+		err = c.buildParallel(ctx, c.ordered(), o.workers)
+	} else {
+		err = c.buildSequential(ctx, c.ordered())
+	}
 
-		for i := 0; i < providerType.NumIn(); i++ {
-			val := c.items[providerType.In(i)].Value
-			args = append(args, reflect.ValueOf(val))
+	if err != nil {
+		if closeErr := c.Close(context.Background()); closeErr != nil {
+			return errors.Join(err, closeErr)
 		}
+		return err
+	}
+
+	return nil
+}
 
-		// Call the provider.
-		result := item.provider.Call(args)
-		if len(result) == 2 && !result[1].IsNil() {
-			// We hardcoded max 2 return types for the provider.
-			// The second value is the error.
-			return result[1].Interface().(error)
+// buildSequential builds the singleton items in order.
+func (c *Container) buildSequential(ctx context.Context, items []*Item) error {
+	for _, item := range items {
+		if item.lifetime == transient {
+			// Transient items are created on demand.
+			continue
 		}
-		if !result[0].IsValid() {
-			panic("invalid value")
+
+		if _, err := c.build(ctx, item); err != nil {
+			return err
 		}
+	}
 
-		item.Value = result[0].Interface()
+	return nil
+}
+
+func (c *Container) setClosed(closed bool) {
+	c.mu.Lock()
+	c.closed = closed
+	c.mu.Unlock()
+}
+
+// BuildFor builds the items of the given types and their dependencies.
+// The types must be passed as nil pointers, e.g. c.BuildFor((*Config)(nil)).
+func (c *Container) BuildFor(types ...interface{}) error {
+	c.setClosed(false)
+
+	for _, typ := range types {
+		k := key{typ: reflectType(typ)}
+		item, ok := c.lookup(k)
+		if !ok {
+			return fmt.Errorf("container: type '%s': %w", k, ErrNotRegistered)
+		}
+		if item.lifetime == transient {
+			continue
+		}
+		if _, err := item.owner.build(context.Background(), item); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// Get returns a built dependency by type.
+// Invoke calls fn with its parameters resolved from the container
+// like the parameters of a provider. fn may return an error,
+// which is returned from Invoke.
+func (c *Container) Invoke(fn interface{}) error {
+	fnType := reflect.TypeOf(fn)
+	if fnType == nil || fnType.Kind() != reflect.Func {
+		panic("container: invoked value must be a function")
+	}
+	if fnType.NumOut() > 1 || (fnType.NumOut() == 1 && !fnType.Out(0).Implements(errorType)) {
+		panic(fmt.Errorf("container: invoked function '%s' may only return an error", fnType))
+	}
+
+	params := options{}.params(fnType)
+
+	var errs []error
+	for _, p := range params {
+		if _, err := c.resolveParam(fnType, p); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	args := make([]reflect.Value, 0, len(params))
+	for _, p := range params {
+		arg, err := c.argValue(context.Background(), p)
+		if err != nil {
+			return err
+		}
+		args = append(args, arg)
+	}
+
+	result := reflect.ValueOf(fn).Call(args)
+	if len(result) == 1 && !result[0].IsNil() {
+		return result[0].Interface().(error)
+	}
+
+	return nil
+}
+
+// Get returns a dependency by type, building it on first access.
+// A new instance is created for transient items.
 func (c *Container) Get(typ interface{}) interface{} {
 	tp := reflectType(typ)
-	item, ok := c.items[tp]
+	item, ok := c.lookup(key{typ: tp})
 	if !ok {
 		panic(fmt.Errorf("container: item with type '%T' not found", typ))
 	}
-	return item.Value
+	value, err := c.itemValue(context.Background(), item, tp)
+	if err != nil {
+		panic(err)
+	}
+	return value.Interface()
+}
+
+// Get returns a dependency of type T, building it on first access.
+func Get[T any](c *Container) (T, error) {
+	return GetNamed[T](c, "")
+}
+
+// GetNamed returns a dependency of type T registered under name,
+// building it on first access. A new instance is created for transient items.
+func GetNamed[T any](c *Container, name string) (T, error) {
+	var zero T
+	k := key{typ: reflect.TypeOf((*T)(nil)).Elem(), name: name}
+
+	item, ok := c.lookup(k)
+	if !ok {
+		return zero, fmt.Errorf("container: type '%s': %w", k, ErrNotRegistered)
+	}
+
+	v, err := c.itemValue(context.Background(), item, k.typ)
+	if err != nil {
+		return zero, err
+	}
+
+	// A provider may return a nil value, in which case the zero value is returned.
+	value, _ := v.Interface().(T)
+	return value, nil
+}
+
+// GetGroup returns the items of a group as a slice of T,
+// building them on first access.
+func GetGroup[T any](c *Container, group string) ([]T, error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	members, ok := c.groupMembers(group)
+	if !ok {
+		return nil, fmt.Errorf("container: group '%s': %w", group, ErrNotRegistered)
+	}
+
+	values := make([]T, 0, len(members))
+	for _, member := range members {
+		if !member.key.typ.AssignableTo(typ) {
+			return nil, fmt.Errorf("container: type '%s' of group '%s' is not assignable to '%s'", member.key.typ, group, typ)
+		}
+		v, err := c.itemValue(context.Background(), member, typ)
+		if err != nil {
+			return nil, err
+		}
+		value, _ := v.Interface().(T)
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+// MustGet is like Get but panics on error.
+func MustGet[T any](c *Container) T {
+	return MustGetNamed[T](c, "")
+}
+
+// MustGetNamed is like GetNamed but panics on error.
+func MustGetNamed[T any](c *Container, name string) T {
+	value, err := GetNamed[T](c, name)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// valueOf returns the reflect value of an item value.
+// A nil value is converted to the zero value of typ.
+func valueOf(value interface{}, typ reflect.Type) reflect.Value {
+	if value == nil {
+		return reflect.Zero(typ)
+	}
+	return reflect.ValueOf(value)
 }
 
 func reflectType(typ interface{}) reflect.Type {
@@ -1,9 +1,18 @@
 package di
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
+
+	"github.com/mgnsk/di-container/internal/render"
 )
 
 type myint int
@@ -126,8 +135,27 @@ func TestDependencyLoop(t *testing.T) {
 	c.Register(bytesProvider)
 	c.Register(stringProvider)
 
-	if err := c.Resolve(); err == nil {
-		t.Fatal("expected dependency loop error")
+	err := c.Resolve()
+
+	var cycle *CycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("expected CycleError, got %v", err)
+	}
+
+	var path []string
+	for _, typ := range cycle.Path {
+		path = append(path, typ.String())
+	}
+	if got := strings.Join(path, " -> "); got != "int -> []uint8 -> string -> int" {
+		t.Fatalf("expected path 'int -> []uint8 -> string -> int', got '%s'", got)
+	}
+
+	if len(cycle.Providers) != 3 || !strings.Contains(cycle.Providers[0], "TestDependencyLoop") {
+		t.Fatalf("expected provider names, got %v", cycle.Providers)
+	}
+
+	if !strings.HasPrefix(err.Error(), "Dependency cycle detected: int -> []uint8 -> string -> int (providers: ") {
+		t.Fatalf("unexpected error message '%s'", err)
 	}
 }
 
@@ -167,3 +195,1303 @@ func TestContainer(t *testing.T) {
 		t.Fatal("invalid greeting")
 	}
 }
+
+func TestGet(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(newMyInt)
+	c.Register(newMyMultiplier)
+	c.Register(newMySentence)
+
+	if _, err := Get[mysentence](c); !errors.Is(err, ErrNotBuilt) {
+		t.Fatalf("expected ErrNotBuilt, got %v", err)
+	}
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Get[mysentence](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "hello world 42!" {
+		t.Fatal("invalid sentence")
+	}
+
+	if _, err := Get[greeter](c); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("expected ErrNotRegistered, got %v", err)
+	}
+
+	if MustGet[myint](c) != 21 {
+		t.Fatal("invalid int")
+	}
+}
+
+func TestMustGetPanics(t *testing.T) {
+	c := NewContainer()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+
+	MustGet[myint](c)
+}
+
+func TestBind(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(newMyInt)
+	c.Register(newMyMultiplier)
+	c.Register(newMySentence)
+	c.Register(newMyGreeter)
+	c.Bind((*greeter)(nil), (**mygreeter)(nil))
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	g := MustGet[greeter](c)
+	if g.greet() != "hello world 42!" {
+		t.Fatal("invalid sentence")
+	}
+
+	if g != MustGet[*mygreeter](c) {
+		t.Fatal("expected the same instance")
+	}
+}
+
+type othergreeter struct{}
+
+func (othergreeter) greet() string {
+	return "other"
+}
+
+func TestBindAmbiguous(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(newMyInt)
+	c.Register(newMyMultiplier)
+	c.Register(newMySentence)
+	c.Register(newMyGreeter)
+	c.Register(func() othergreeter {
+		return othergreeter{}
+	})
+	c.Bind((*greeter)(nil), (**mygreeter)(nil))
+	c.Bind((*greeter)(nil), (*othergreeter)(nil))
+
+	if err := c.Resolve(); err == nil {
+		t.Fatal("expected ambiguous binding error")
+	}
+}
+
+func TestBindNotImplemented(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(newMyInt)
+	c.Bind((*greeter)(nil), (*myint)(nil))
+
+	if err := c.Resolve(); err == nil {
+		t.Fatal("expected binding error")
+	}
+}
+
+type db struct {
+	dsn string
+}
+
+type repo struct {
+	primary *db
+	replica *db
+}
+
+func TestRegisterNamed(t *testing.T) {
+	c := NewContainer()
+
+	c.RegisterNamed("primary", func() *db {
+		return &db{"primary"}
+	})
+	c.RegisterNamed("replica", func() *db {
+		return &db{"replica"}
+	})
+	c.Register(func(primary, replica *db) *repo {
+		return &repo{primary, replica}
+	}, ParamTags(`name:"primary"`, `name:"replica"`))
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := MustGet[*repo](c)
+	if r.primary.dsn != "primary" || r.replica.dsn != "replica" {
+		t.Fatal("invalid named dependencies")
+	}
+
+	if MustGetNamed[*db](c, "replica") != r.replica {
+		t.Fatal("expected the same instance")
+	}
+
+	if _, err := Get[*db](c); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("expected ErrNotRegistered, got %v", err)
+	}
+}
+
+func TestMissingNamedProvider(t *testing.T) {
+	c := NewContainer()
+
+	c.RegisterNamed("primary", func() *db {
+		return &db{"primary"}
+	})
+	c.Register(func(replica *db) *repo {
+		return &repo{replica: replica}
+	}, ParamTags(`name:"replica"`))
+
+	if err := c.Resolve(); err == nil {
+		t.Fatal("expected resolve error")
+	} else if !strings.Contains(err.Error(), `name="replica"`) {
+		t.Fatalf("expected missing replica provider, got %v", err)
+	}
+}
+
+type handler interface {
+	path() string
+}
+
+type usershandler struct{}
+
+func (usershandler) path() string {
+	return "/users"
+}
+
+type ordershandler struct{}
+
+func (*ordershandler) path() string {
+	return "/orders"
+}
+
+type server struct {
+	handlers []handler
+}
+
+func TestGroup(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(func(handlers []handler) *server {
+		return &server{handlers}
+	}, ParamTags(`group:"handlers"`))
+	c.Register(func() usershandler {
+		return usershandler{}
+	}, Group("handlers"))
+	c.Register(func() (*ordershandler, error) {
+		return &ordershandler{}, nil
+	}, Group("handlers"))
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	s := MustGet[*server](c)
+	if len(s.handlers) != 2 || s.handlers[0].path() != "/users" || s.handlers[1].path() != "/orders" {
+		t.Fatal("invalid group")
+	}
+
+	handlers, err := GetGroup[handler](c, "handlers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(handlers) != 2 || handlers[1] != s.handlers[1] {
+		t.Fatal("expected the same instances")
+	}
+}
+
+func TestEmptyGroup(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(func(handlers []handler) *server {
+		return &server{handlers}
+	}, ParamTags(`group:"handlers"`))
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := MustGet[*server](c); len(s.handlers) != 0 {
+		t.Fatal("expected empty group")
+	}
+}
+
+func TestGroupNotAssignable(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(func(handlers []handler) *server {
+		return &server{handlers}
+	}, ParamTags(`group:"handlers"`))
+	c.Register(newMyInt, Group("handlers"))
+
+	if err := c.Resolve(); err == nil {
+		t.Fatal("expected resolve error")
+	}
+}
+
+type closer struct {
+	name   string
+	closed *[]string
+}
+
+func (c *closer) Close() error {
+	*c.closed = append(*c.closed, c.name)
+	return fmt.Errorf("%s failed", c.name)
+}
+
+type stopper struct {
+	started bool
+	stopped bool
+}
+
+func (s *stopper) Start(context.Context) error {
+	s.started = true
+	return nil
+}
+
+func (s *stopper) Stop(context.Context) error {
+	s.stopped = true
+	return nil
+}
+
+func TestClose(t *testing.T) {
+	c := NewContainer()
+
+	var closed []string
+
+	c.Register(func(s *stopper) *closer {
+		return &closer{"first", &closed}
+	})
+	c.RegisterNamed("second", func(first *closer) *closer {
+		return &closer{"second", &closed}
+	}, OnClose(func(ctx context.Context, c *closer) error {
+		closed = append(closed, "hook")
+		return nil
+	}))
+	c.Register(func() *stopper {
+		return &stopper{}
+	})
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s := MustGet[*stopper](c)
+	if !s.started {
+		t.Fatal("expected started")
+	}
+
+	err := c.Close(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "first failed") || !strings.Contains(err.Error(), "second failed") {
+		t.Fatalf("expected joined close errors, got %v", err)
+	}
+
+	if strings.Join(closed, ",") != "second,hook,first" {
+		t.Fatalf("invalid close order: %v", closed)
+	}
+
+	if !s.stopped {
+		t.Fatal("expected stopped")
+	}
+
+	if _, err := Get[*stopper](c); !errors.Is(err, ErrNotBuilt) {
+		t.Fatalf("expected ErrNotBuilt, got %v", err)
+	}
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatalf("expected no error on second close, got %v", err)
+	}
+}
+
+func TestBuildErrorCloses(t *testing.T) {
+	c := NewContainer()
+
+	errProvider := errors.New("provider failed")
+	var closed []string
+
+	c.Register(func() *closer {
+		return &closer{"first", &closed}
+	})
+	c.Register(func(*closer) (*stopper, error) {
+		return nil, errProvider
+	})
+	c.Register(func(*stopper) myint {
+		return 0
+	})
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	err := c.Build()
+	if !errors.Is(err, errProvider) {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+
+	if strings.Join(closed, ",") != "first" {
+		t.Fatalf("expected built items to be closed: %v", closed)
+	}
+}
+
+func TestCleanup(t *testing.T) {
+	c := NewContainer()
+
+	var cleaned []string
+
+	c.Register(func() (myint, func(), error) {
+		return 21, func() {
+			cleaned = append(cleaned, "myint")
+		}, nil
+	})
+	c.Register(func(myint) (mymultiplier, func()) {
+		return 2, func() {
+			cleaned = append(cleaned, "mymultiplier")
+		}
+	})
+	c.Register(newMySentence)
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(cleaned) != 0 {
+		t.Fatal("expected no cleanup before Close")
+	}
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(cleaned, ",") != "mymultiplier,myint" {
+		t.Fatalf("invalid cleanup order: %v", cleaned)
+	}
+}
+
+func TestCleanupOnBuildError(t *testing.T) {
+	c := NewContainer()
+
+	errProvider := errors.New("provider failed")
+	var cleaned []string
+
+	c.Register(func() (myint, func(), error) {
+		return 21, func() {
+			cleaned = append(cleaned, "myint")
+		}, nil
+	})
+	c.Register(func(myint) (mymultiplier, func(), error) {
+		return 0, nil, errProvider
+	})
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(); !errors.Is(err, errProvider) {
+		t.Fatalf("expected provider error, got %v", err)
+	}
+
+	if strings.Join(cleaned, ",") != "myint" {
+		t.Fatalf("expected cleanup of built items: %v", cleaned)
+	}
+}
+
+func TestInvalidCleanupProvider(t *testing.T) {
+	c := NewContainer()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+
+	c.Register(func() (myint, error, func()) {
+		return 0, nil, nil
+	})
+}
+
+type dbResults struct {
+	Out
+
+	Primary *db     `name:"primary"`
+	Replica *db     `name:"replica"`
+	Users   handler `group:"handlers"`
+}
+
+type repoParams struct {
+	In
+
+	Primary  *db       `name:"primary"`
+	Replica  *db       `name:"replica"`
+	Greeter  greeter   `optional:"true"`
+	Handlers []handler `group:"handlers"`
+}
+
+func TestInOut(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(func() (dbResults, error) {
+		return dbResults{
+			Primary: &db{"primary"},
+			Replica: &db{"replica"},
+			Users:   usershandler{},
+		}, nil
+	})
+
+	var params repoParams
+	c.Register(func(p repoParams) *repo {
+		params = p
+		return &repo{p.Primary, p.Replica}
+	})
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := MustGet[*repo](c)
+	if r.primary.dsn != "primary" || r.replica.dsn != "replica" {
+		t.Fatal("invalid named dependencies")
+	}
+
+	if params.Greeter != nil {
+		t.Fatal("expected zero value for missing optional dependency")
+	}
+
+	if len(params.Handlers) != 1 || params.Handlers[0].path() != "/users" {
+		t.Fatal("invalid group")
+	}
+
+	if MustGetNamed[*db](c, "primary") != r.primary {
+		t.Fatal("expected the same instance")
+	}
+}
+
+func TestOutGroup(t *testing.T) {
+	c := NewContainer()
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic")
+		}
+	}()
+
+	c.Register(func() dbResults {
+		return dbResults{}
+	}, Group("results"))
+}
+
+func TestInMissingProvider(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(func(p repoParams) *repo {
+		return &repo{p.Primary, p.Replica}
+	})
+
+	if err := c.Resolve(); err == nil {
+		t.Fatal("expected resolve error")
+	} else if !strings.Contains(err.Error(), `name="primary"`) {
+		t.Fatalf("expected missing primary provider, got %v", err)
+	}
+}
+
+func TestOptional(t *testing.T) {
+	c := NewContainer()
+
+	var (
+		sentence Optional[mysentence]
+		mult     Optional[mymultiplier]
+		number   myint
+	)
+
+	c.Register(newMyMultiplier)
+	c.Register(func(s Optional[mysentence], m Optional[mymultiplier], n myint) *repo {
+		sentence, mult, number = s, m, n
+		return &repo{}
+	}, ParamTags("", "", `optional:"true"`))
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	if sentence.OK || sentence.Value != "" {
+		t.Fatal("expected missing optional sentence")
+	}
+
+	if !mult.OK || mult.Value != 2 {
+		t.Fatal("expected optional multiplier")
+	}
+
+	if number != 0 {
+		t.Fatal("expected zero value for missing optional dependency")
+	}
+}
+
+func TestOptionalPointer(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(func(*Optional[mysentence]) *repo {
+		return &repo{}
+	})
+
+	err := c.Resolve()
+	expected := "Missing provider for type '*di.Optional[github.com/mgnsk/di-container/di.mysentence]' required by '*di.repo'"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected '%s', got '%v'", expected, err)
+	}
+}
+
+type buffer struct {
+	n int
+}
+
+func TestTransient(t *testing.T) {
+	c := NewContainer()
+
+	var created int
+	c.Register(func() *buffer {
+		created++
+		return &buffer{created}
+	}, Transient)
+
+	var factory Provider[*buffer]
+	c.Register(func(p Provider[*buffer], f func() (myint, error)) mysentence {
+		factory = p
+		n, err := f()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return mysentence(fmt.Sprint(n))
+	})
+	c.Register(newMyInt, Singleton)
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	if created != 0 {
+		t.Fatal("expected transient items not to be built")
+	}
+
+	if MustGet[mysentence](c) != "21" {
+		t.Fatal("invalid sentence")
+	}
+
+	first := MustGet[*buffer](c)
+	second := MustGet[*buffer](c)
+	if first == second || created != 2 {
+		t.Fatal("expected new transient instances")
+	}
+
+	b, err := factory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.n != 3 {
+		t.Fatal("expected the factory to create a new instance")
+	}
+}
+
+func TestSingletonCapturesTransient(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(func() *buffer {
+		return &buffer{}
+	}, Transient)
+	c.Register(func(*buffer) myint {
+		return 0
+	})
+
+	if err := c.Resolve(); err == nil {
+		t.Fatal("expected resolve error")
+	} else if !strings.Contains(err.Error(), "transient") {
+		t.Fatalf("expected transient capture error, got %v", err)
+	}
+}
+
+func TestTransientDependsOnTransient(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(func() *buffer {
+		return &buffer{}
+	}, Transient)
+	c.Register(func(b *buffer) myint {
+		return myint(b.n)
+	}, Transient)
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type requestid string
+
+type requestlogger struct {
+	id       requestid
+	sentence mysentence
+	closed   bool
+}
+
+func (l *requestlogger) Close() error {
+	l.closed = true
+	return nil
+}
+
+func TestScope(t *testing.T) {
+	parent := NewContainer()
+
+	var built int
+	parent.Register(func(n myint, m mymultiplier) mysentence {
+		built++
+		return newMySentence(n, m)
+	})
+	parent.Register(newMyInt)
+	parent.Register(newMyMultiplier)
+
+	if err := parent.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := parent.Build(); err != nil {
+		t.Fatal(err)
+	}
+
+	newScope := func(id requestid) *Container {
+		scope := parent.Scope()
+		scope.RegisterValue(id)
+		scope.Register(func(id requestid, s mysentence) *requestlogger {
+			return &requestlogger{id: id, sentence: s}
+		})
+		scope.Register(func() myint {
+			return 1
+		})
+
+		if err := scope.Resolve(); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := scope.Build(); err != nil {
+			t.Fatal(err)
+		}
+
+		return scope
+	}
+
+	first := newScope("first")
+	second := newScope("second")
+
+	if built != 1 {
+		t.Fatal("expected parent items not to be rebuilt")
+	}
+
+	firstLogger := MustGet[*requestlogger](first)
+	secondLogger := MustGet[*requestlogger](second)
+
+	if firstLogger.id != "first" || secondLogger.id != "second" {
+		t.Fatal("invalid scope-local values")
+	}
+
+	if firstLogger.sentence != "hello world 42!" {
+		t.Fatal("expected parent dependency")
+	}
+
+	if MustGet[myint](first) != 1 || MustGet[myint](parent) != 21 {
+		t.Fatal("expected the child to override the parent item")
+	}
+
+	if err := first.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !firstLogger.closed || secondLogger.closed {
+		t.Fatal("expected only the scope-local items to be closed")
+	}
+
+	if MustGet[mysentence](parent) != "hello world 42!" {
+		t.Fatal("expected parent items to stay built")
+	}
+}
+
+func TestScopeMissingProvider(t *testing.T) {
+	parent := NewContainer()
+	parent.Register(newMyInt)
+
+	if err := parent.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	scope := parent.Scope()
+	scope.Register(newMySentence)
+
+	if err := scope.Resolve(); err == nil {
+		t.Fatal("expected resolve error")
+	} else if !strings.Contains(err.Error(), "di.mymultiplier") {
+		t.Fatalf("expected missing di.mymultiplier provider, got %v", err)
+	}
+}
+
+func TestLazyBuild(t *testing.T) {
+	c := NewContainer()
+
+	var calls []string
+	c.Register(func() myint {
+		calls = append(calls, "int")
+		return 42
+	})
+	c.Register(func() mymultiplier {
+		calls = append(calls, "multiplier")
+		return 2
+	})
+	c.Register(func(i myint) mysentence {
+		calls = append(calls, "sentence")
+		return mysentence(fmt.Sprint(i))
+	})
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 0 {
+		t.Fatalf("expected no providers to be called, got %v", calls)
+	}
+
+	if err := c.BuildFor((*mysentence)(nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Get[mysentence](c); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := strings.Join(calls, ","); got != "int,sentence" {
+		t.Fatalf("expected int,sentence, got %s", got)
+	}
+
+	if err := c.BuildFor((*greeter)(nil)); !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("expected ErrNotRegistered, got %v", err)
+	}
+}
+
+func TestLazyBuildErrorPath(t *testing.T) {
+	c := NewContainer()
+
+	errInt := errors.New("no int")
+	c.Register(func() (myint, error) {
+		return 0, errInt
+	})
+	c.Register(newMyMultiplier)
+	c.Register(newMySentence)
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := Get[mysentence](c)
+	if !errors.Is(err, errInt) {
+		t.Fatalf("expected errInt, got %v", err)
+	}
+
+	var pe *ProviderError
+	if !errors.As(err, &pe) {
+		t.Fatalf("expected ProviderError, got %v", err)
+	}
+	if pe.Type != reflect.TypeOf(myint(0)) {
+		t.Fatalf("expected di.myint, got %s", pe.Type)
+	}
+	if len(pe.Path) != 2 || pe.Path[0] != reflect.TypeOf(mysentence("")) {
+		t.Fatalf("expected path from di.mysentence, got %v", pe.Path)
+	}
+	if !strings.Contains(pe.Provider, "TestLazyBuildErrorPath") || !strings.Contains(pe.Provider, "container_test.go:") {
+		t.Fatalf("expected provider name and location, got '%s'", pe.Provider)
+	}
+
+	expected := "container: building 'di.mysentence' -> 'di.myint': provider " + pe.Provider + ": no int"
+	if err.Error() != expected {
+		t.Fatalf("expected '%s', got '%s'", expected, err.Error())
+	}
+}
+
+func TestInvoke(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(newMyInt)
+	c.Register(newMyMultiplier)
+	c.Register(newMySentence)
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	var got mysentence
+	if err := c.Invoke(func(s mysentence, o Optional[greeter]) {
+		got = s
+		if o.OK {
+			t.Fatal("expected no greeter")
+		}
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if got != "hello world 42!" {
+		t.Fatal("invalid sentence")
+	}
+
+	errInvoke := errors.New("invoke")
+	if err := c.Invoke(func(myint) error { return errInvoke }); !errors.Is(err, errInvoke) {
+		t.Fatalf("expected errInvoke, got %v", err)
+	}
+
+	err := c.Invoke(func(myint, greeter, *myservice) {})
+	expected := "Missing provider for type 'di.greeter' required by 'func(di.myint, di.greeter, *di.myservice)'\n" +
+		"Missing provider for type '*di.myservice' required by 'func(di.myint, di.greeter, *di.myservice)'"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected '%s', got '%v'", expected, err)
+	}
+}
+
+func TestInvokeInvalidSignature(t *testing.T) {
+	for _, fn := range []interface{}{
+		42,
+		func() int { return 0 },
+		func() (error, error) { return nil, nil },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("expected Invoke(%T) to panic", fn)
+				}
+			}()
+			NewContainer().Invoke(fn)
+		}()
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	const n = 50
+
+	c := NewContainer()
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.RegisterNamed(fmt.Sprint(i), func() int { return i })
+			c.Register(func() myint { return myint(i) }, Group("ints"))
+		}()
+	}
+	wg.Wait()
+
+	var calls int32
+	c.Register(func(ints []myint) mysentence {
+		atomic.AddInt32(&calls, 1)
+		return mysentence(fmt.Sprint(len(ints)))
+	}, ParamTags(`group:"ints"`))
+	c.Register(newMyMultiplier, Transient)
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < n; i++ {
+		i := i
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			if v, err := GetNamed[int](c, fmt.Sprint(i)); err != nil || v != i {
+				t.Errorf("expected %d, got %d: %v", i, v, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if s, err := Get[mysentence](c); err != nil || s != mysentence(fmt.Sprint(n)) {
+				t.Errorf("expected %d, got %s: %v", n, s, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := c.Invoke(func(s mysentence, m mymultiplier) {}); err != nil {
+				t.Error(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			scope := c.Scope()
+			scope.RegisterValue(myint(i))
+			if err := scope.Resolve(); err != nil {
+				t.Error(err)
+				return
+			}
+			if v, err := Get[myint](scope); err != nil || v != myint(i) {
+				t.Errorf("expected %d, got %d: %v", i, v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Fatalf("expected the singleton provider to be called once, got %d", calls)
+	}
+
+	if err := c.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTransientNotResolved(t *testing.T) {
+	c := NewContainer()
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Register(func(n myint) *buffer {
+		return &buffer{int(n)}
+	}, Transient)
+
+	if _, err := Get[*buffer](c); !errors.Is(err, ErrNotBuilt) {
+		t.Fatalf("expected ErrNotBuilt before resolving again, got %v", err)
+	}
+
+	if err := c.Resolve(); err == nil {
+		t.Fatal("expected a missing provider error")
+	}
+}
+
+func TestParallelBuild(t *testing.T) {
+	c := NewContainer()
+
+	var running, maxRunning int32
+	slow := func(v int) func() int {
+		return func() int {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			return v
+		}
+	}
+
+	c.RegisterNamed("a", slow(1))
+	c.RegisterNamed("b", slow(2))
+	c.RegisterNamed("c", slow(3))
+
+	type sum int
+	c.Register(func(p struct {
+		In
+		A int `name:"a"`
+		B int `name:"b"`
+		C int `name:"c"`
+	}) sum {
+		return sum(p.A + p.B + p.C)
+	})
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(Parallel(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if maxRunning != 2 {
+		t.Fatalf("expected 2 providers running at a time, got %d", maxRunning)
+	}
+
+	if s, err := Get[sum](c); err != nil || s != 6 {
+		t.Fatalf("expected 6, got %d: %v", s, err)
+	}
+}
+
+func TestParallelBuildContext(t *testing.T) {
+	c := NewContainer()
+
+	var providerCtx context.Context
+	c.Register(func(ctx context.Context) myint {
+		providerCtx = ctx
+		return 21
+	})
+	c.Register(newMyMultiplier)
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(Parallel(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := providerCtx.Err(); err != nil {
+		t.Fatalf("expected the provider context to be live after Build, got %v", err)
+	}
+}
+
+func TestParallelBuildError(t *testing.T) {
+	c := NewContainer()
+
+	errInt := errors.New("no int")
+	c.Register(func() (myint, error) {
+		return 0, errInt
+	})
+	c.Register(func() mymultiplier {
+		time.Sleep(10 * time.Millisecond)
+		return 2
+	})
+
+	var called bool
+	c.Register(func(myint, mymultiplier) mysentence {
+		called = true
+		return ""
+	})
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Build(Parallel(4)); !errors.Is(err, errInt) {
+		t.Fatalf("expected errInt, got %v", err)
+	}
+
+	if called {
+		t.Fatal("expected dependent provider not to be called")
+	}
+
+	if _, err := Get[mymultiplier](c); !errors.Is(err, ErrNotBuilt) {
+		t.Fatalf("expected ErrNotBuilt, got %v", err)
+	}
+}
+
+func TestBuildContext(t *testing.T) {
+	type ctxKey struct{}
+
+	c := NewContainer()
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "value"))
+	defer cancel()
+
+	c.Register(func(ctx context.Context) myint {
+		if ctx.Value(ctxKey{}) != "value" {
+			t.Fatal("expected the build context")
+		}
+		// Cancel the build after the first provider.
+		cancel()
+		return 21
+	})
+
+	var called bool
+	c.Register(func(myint) mymultiplier {
+		called = true
+		return 2
+	})
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.BuildContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if called {
+		t.Fatal("expected build to abort before the next provider")
+	}
+}
+
+func TestMissingProviders(t *testing.T) {
+	c := NewContainer()
+
+	c.Register(newMySentence)
+	c.Register(func(s mysentence, g greeter) factory {
+		return nil
+	})
+
+	err := c.Resolve()
+
+	var missing *MissingProviderError
+	if !errors.As(err, &missing) {
+		t.Fatalf("expected MissingProviderError, got %v", err)
+	}
+	if !errors.Is(err, ErrNotRegistered) {
+		t.Fatalf("expected ErrNotRegistered, got %v", err)
+	}
+	if missing.Type != reflect.TypeOf(myint(0)) || missing.RequiredBy != reflect.TypeOf(mysentence("")) {
+		t.Fatalf("expected di.myint required by di.mysentence, got %s required by %s", missing.Type, missing.RequiredBy)
+	}
+
+	expected := "Missing provider for type 'di.myint' required by 'di.mysentence'\n" +
+		"Missing provider for type 'di.mymultiplier' required by 'di.mysentence'\n" +
+		"Missing provider for type 'di.greeter' required by 'di.factory'"
+	if err.Error() != expected {
+		t.Fatalf("expected '%s', got '%s'", expected, err.Error())
+	}
+}
+
+func TestResolveAgain(t *testing.T) {
+	c := NewContainer()
+
+	var calls int
+	c.Register(func() myint {
+		calls++
+		return 21
+	})
+	c.Register(newMyMultiplier)
+
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Get[myint](c); err != nil {
+		t.Fatal(err)
+	}
+
+	c.Register(newMySentence)
+
+	if _, err := Get[mysentence](c); !errors.Is(err, ErrNotBuilt) {
+		t.Fatalf("expected ErrNotBuilt before resolving again, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := c.Resolve(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := Get[mysentence](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s != "hello world 42!" {
+		t.Fatal("invalid sentence")
+	}
+
+	if calls != 1 {
+		t.Fatalf("expected the provider to be called once, got %d", calls)
+	}
+
+	c.Range(func(item *Item) bool {
+		if item.key.typ == reflect.TypeOf(mysentence("")) && len(c.deps.Edges(item)) != 2 {
+			t.Fatalf("expected 2 edges, got %d", len(c.deps.Edges(item)))
+		}
+		return true
+	})
+}
+
+func newGraphContainer() *Container {
+	c := NewContainer()
+
+	c.Register(newMyInt)
+	c.RegisterNamed("double", func(i myint) myint { return i * 2 })
+	c.Register(newMyMultiplier, Transient)
+	c.RegisterValue(mysentence("hello"), Group("sentences"))
+	c.Register(func(p struct {
+		In
+		Ints      Provider[mymultiplier]
+		Sentences []mysentence `group:"sentences"`
+		Double    myint        `name:"double"`
+		Greeter   greeter      `optional:"true"`
+	}) factory {
+		return nil
+	})
+
+	return c
+}
+
+func TestWriteGraph(t *testing.T) {
+	c := newGraphContainer()
+	if err := c.Resolve(); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := c.WriteGraph(&b, FormatDOT); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `digraph container {
+	node [shape=box];
+	n0 [label="di.myint\ndi.newMyInt"];
+	n1 [label="di.myint name=\"double\"\ndi.newGraphContainer.func1"];
+	n2 [label="di.mymultiplier\ndi.newMyMultiplier\n(transient)" style=dashed];
+	n3 [label="di.mysentence\n(group: sentences)"];
+	n4 [label="di.factory\ndi.newGraphContainer.func2"];
+	n1 -> n0;
+	n4 -> n2 [label="factory" style=dashed];
+	n4 -> n3;
+	n4 -> n1;
+}
+`
+	if b.String() != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, b.String())
+	}
+
+	b.Reset()
+	if err := c.WriteGraph(&b, FormatMermaid); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `n1["di.myint name=#quot;double#quot;<br/>di.newGraphContainer.func1"]`) ||
+		!strings.Contains(b.String(), "n4 -.->|factory| n2") {
+		t.Fatalf("unexpected mermaid output:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := c.WriteGraph(&b, FormatJSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var g render.Graph
+	if err := json.Unmarshal([]byte(b.String()), &g); err != nil {
+		t.Fatal(err)
+	}
+	if len(g.Nodes) != 5 || len(g.Edges) != 4 || g.Nodes[2].Lifetime != "transient" || g.Nodes[3].Group != "sentences" {
+		t.Fatalf("unexpected json output:\n%s", b.String())
+	}
+}
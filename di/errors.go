@@ -0,0 +1,115 @@
+package di
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+var (
+	// ErrNotRegistered is returned when no provider is registered for a type.
+	ErrNotRegistered = errors.New("not registered")
+
+	// ErrNotBuilt is returned when an item is requested from a container
+	// that is not resolved or has been closed.
+	ErrNotBuilt = errors.New("not built yet")
+)
+
+// MissingProviderError is returned when no provider is registered
+// for a dependency. It matches ErrNotRegistered.
+type MissingProviderError struct {
+	// Type of the missing dependency.
+	Type reflect.Type
+	// Name of the missing dependency, if named.
+	Name string
+	// RequiredBy is the type of the item or the function depending on Type.
+	RequiredBy reflect.Type
+}
+
+func (e *MissingProviderError) Error() string {
+	k := key{typ: e.Type, name: e.Name}
+	if e.RequiredBy == nil {
+		return fmt.Sprintf("Missing provider for type '%s'", k)
+	}
+	return fmt.Sprintf("Missing provider for type '%s' required by '%s'", k, e.RequiredBy)
+}
+
+// Is reports whether target is ErrNotRegistered.
+func (e *MissingProviderError) Is(target error) bool {
+	return target == ErrNotRegistered
+}
+
+// CycleError is returned when the dependencies contain a cycle.
+type CycleError struct {
+	// Path of the types in the cycle, each depending on the next.
+	// The first type is repeated at the end.
+	Path []reflect.Type
+	// Providers of the types in Path, without the repeated type.
+	Providers []string
+}
+
+func (e *CycleError) Error() string {
+	path := make([]string, len(e.Path))
+	for i, typ := range e.Path {
+		path[i] = typ.String()
+	}
+	return fmt.Sprintf("Dependency cycle detected: %s (providers: %s)", strings.Join(path, " -> "), strings.Join(e.Providers, ", "))
+}
+
+// newCycleError creates an error for a cycle of items.
+func newCycleError(items []*Item) *CycleError {
+	e := &CycleError{}
+	for i, item := range items {
+		e.Path = append(e.Path, item.key.typ)
+		if i < len(items)-1 {
+			e.Providers = append(e.Providers, funcName(item.provider))
+		}
+	}
+	return e
+}
+
+// ProviderError is returned when a provider fails.
+type ProviderError struct {
+	// Type provided by the failed provider.
+	Type reflect.Type
+	// Provider is the function name and source location of the provider.
+	Provider string
+	// Path of the types from the requested item to Type.
+	Path []reflect.Type
+	// Err is the error returned by the provider.
+	Err error
+}
+
+func (e *ProviderError) Error() string {
+	path := make([]string, len(e.Path))
+	for i, typ := range e.Path {
+		path[i] = fmt.Sprintf("'%s'", typ)
+	}
+	return fmt.Sprintf("container: building %s: provider %s: %s", strings.Join(path, " -> "), e.Provider, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// newProviderError creates an error for the failed provider of an item.
+func newProviderError(item *Item, err error) *ProviderError {
+	return &ProviderError{
+		Type:     item.key.typ,
+		Provider: funcName(item.provider),
+		Path:     []reflect.Type{item.key.typ},
+		Err:      err,
+	}
+}
+
+// funcName returns the name and the source location of a function.
+func funcName(fn reflect.Value) string {
+	f := runtime.FuncForPC(fn.Pointer())
+	if f == nil {
+		return fn.Type().String()
+	}
+	file, line := f.FileLine(f.Entry())
+	return fmt.Sprintf("%s (%s:%d)", f.Name(), file, line)
+}
@@ -0,0 +1,105 @@
+package di
+
+import (
+	"io"
+	"runtime"
+	"strings"
+
+	"github.com/mgnsk/di-container/internal/render"
+)
+
+// Format is an output format of WriteGraph.
+type Format = render.Format
+
+const (
+	// FormatDOT is the Graphviz DOT format.
+	FormatDOT = render.DOT
+	// FormatMermaid is a Mermaid flowchart.
+	FormatMermaid = render.Mermaid
+	// FormatJSON is a JSON object of nodes and edges.
+	FormatJSON = render.JSON
+)
+
+// ParseFormat parses a format name: dot, mermaid or json.
+func ParseFormat(name string) (Format, error) {
+	return render.ParseFormat(name)
+}
+
+// WriteGraph writes the items of the container and their dependencies to w.
+// Each item is labeled with its type and provider and marked with its lifetime
+// and group. Edges point from an item to its dependencies and are marked
+// when the dependency is optional or injected through a factory.
+// Dependencies on items of parent containers are not included.
+func (c *Container) WriteGraph(w io.Writer, format Format) error {
+	return render.Write(w, c.graph(), format)
+}
+
+// graph creates the graph of the items in dependency order.
+func (c *Container) graph() render.Graph {
+	return render.Build(c.ordered(), func(item *Item) render.Node {
+		return render.Node{
+			Type:     item.key.typ.String(),
+			Name:     item.key.name,
+			Provider: providerName(item),
+			Lifetime: item.lifetime.String(),
+			Group:    item.group,
+		}
+	}, func(item *Item) []render.Dep[*Item] {
+		var deps []render.Dep[*Item]
+		for _, p := range item.params {
+			deps = append(deps, c.paramDeps(p)...)
+		}
+		return deps
+	})
+}
+
+// paramDeps returns the dependencies of a provider parameter.
+// Keep in sync with container.paramDeps in internal/initgen.
+func (c *Container) paramDeps(p param) []render.Dep[*Item] {
+	switch {
+	case p.ctx:
+		return nil
+
+	case p.in:
+		var deps []render.Dep[*Item]
+		for _, f := range p.fields {
+			deps = append(deps, c.paramDeps(f)...)
+		}
+		return deps
+
+	case p.group != "":
+		members, _ := c.groupMembers(p.group)
+		deps := make([]render.Dep[*Item], 0, len(members))
+		for _, member := range members {
+			deps = append(deps, render.Dep[*Item]{Item: member})
+		}
+		return deps
+
+	default:
+		item, factory, ok := c.lookupParam(p)
+		if !ok {
+			return nil
+		}
+		return []render.Dep[*Item]{{Item: item, Optional: p.optional, Factory: factory}}
+	}
+}
+
+// providerName returns the package qualified name of the provider of an item.
+// Keep in sync with providerName in internal/initgen.
+func providerName(item *Item) string {
+	if item.field != "" {
+		return item.params[0].key.typ.String() + "." + item.field
+	}
+
+	f := runtime.FuncForPC(item.provider.Pointer())
+	if f == nil || strings.HasPrefix(f.Name(), "reflect.") {
+		// Values registered with RegisterValue.
+		return ""
+	}
+
+	name := f.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
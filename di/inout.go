@@ -0,0 +1,103 @@
+package di
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// In is embedded in a parameter struct of a provider.
+// Each exported field of the struct is injected as a dependency.
+// Fields may be tagged with `name:"..."` to depend on a named item,
+// `group:"..."` to depend on a value group or `optional:"true"` to
+// receive the zero value when no provider exists.
+type In struct{}
+
+// Out is embedded in a result struct of a provider.
+// Each exported field of the struct is registered as a separate item.
+// Fields may be tagged with `name:"..."` to register a named item
+// or `group:"..."` to add the item to a value group.
+type Out struct{}
+
+var (
+	inType  = reflect.TypeOf(In{})
+	outType = reflect.TypeOf(Out{})
+)
+
+// embeds reports whether typ is a struct embedding the marker type.
+func embeds(typ, marker reflect.Type) bool {
+	if typ.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if f := typ.Field(i); f.Anonymous && f.Type == marker {
+			return true
+		}
+	}
+	return false
+}
+
+// newInParam creates a parameter for a struct embedding In.
+// Keep in sync with newInParam in internal/initgen.
+func newInParam(typ reflect.Type) param {
+	p := param{
+		key: key{typ: typ},
+		in:  true,
+	}
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Anonymous && f.Type == inType {
+			continue
+		}
+		if f.PkgPath != "" {
+			panic(fmt.Errorf("container: field '%s' of parameter struct '%s' must be exported", f.Name, typ))
+		}
+
+		fp := newParam(f.Type, f.Tag)
+		fp.field = i
+		p.fields = append(p.fields, fp)
+	}
+
+	return p
+}
+
+// registerOut registers each field of a struct embedding Out
+// as a separate item provided by the struct item.
+// Keep in sync with container.addOut in internal/initgen.
+func (c *Container) registerOut(item *Item) {
+	typ := item.key.typ
+
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.Anonymous && f.Type == outType {
+			continue
+		}
+		if f.PkgPath != "" {
+			panic(fmt.Errorf("container: field '%s' of result struct '%s' must be exported", f.Name, typ))
+		}
+
+		index := i
+		extract := reflect.MakeFunc(
+			reflect.FuncOf([]reflect.Type{typ}, []reflect.Type{f.Type}, false),
+			func(args []reflect.Value) []reflect.Value {
+				return []reflect.Value{args[0].Field(index)}
+			},
+		)
+
+		fieldItem := &Item{
+			provider: extract,
+			index:    item.index,
+			key:      key{typ: f.Type, name: f.Tag.Get("name")},
+			group:    f.Tag.Get("group"),
+			params:   []param{{key: item.key}},
+			lifetime: item.lifetime,
+			field:    f.Name,
+		}
+
+		if fieldItem.key.name != "" && fieldItem.group != "" {
+			panic(fmt.Errorf("container: field '%s' of result struct '%s' cannot be both named and grouped", f.Name, typ))
+		}
+
+		c.add(fieldItem)
+	}
+}
@@ -0,0 +1,107 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Starter is implemented by items that need to be started.
+type Starter interface {
+	Start(context.Context) error
+}
+
+// Stopper is implemented by items that need to be stopped.
+type Stopper interface {
+	Stop(context.Context) error
+}
+
+// Start starts the built items in dependency order.
+// For each item, Start is called if the item implements Starter
+// and then the OnStart hooks are called. Start stops on the first error.
+func (c *Container) Start(ctx context.Context) error {
+	for _, item := range c.ordered() {
+
+		item.mu.Lock()
+		value, built := item.Value, item.built
+		item.mu.Unlock()
+
+		if !built || value == nil {
+			continue
+		}
+
+		if s, ok := value.(Starter); ok {
+			if err := s.Start(ctx); err != nil {
+				return fmt.Errorf("container: starting '%s': %w", item.key, err)
+			}
+		}
+
+		for _, h := range item.onStart {
+			if err := h.fn(ctx, value); err != nil {
+				return fmt.Errorf("container: starting '%s': %w", item.key, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// Close closes the built items in reverse dependency order.
+// For each item, Stop is called if the item implements Stopper,
+// otherwise Close is called if the item implements io.Closer.
+// Then the OnClose hooks and the cleanup function returned by
+// the provider are called. Close continues past failures
+// and returns the joined errors. Closed items are no longer built
+// and are not built on access until Build or BuildFor is called again.
+func (c *Container) Close(ctx context.Context) error {
+	var errs []error
+
+	// Items are no longer built on access once closing has started.
+	c.setClosed(true)
+
+	items := c.ordered()
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+
+		item.mu.Lock()
+		value, cleanup, built := item.Value, item.cleanup, item.built
+		item.Value = nil
+		item.cleanup = nil
+		item.built = false
+		item.mu.Unlock()
+
+		if !built {
+			continue
+		}
+
+		if err := closeItem(ctx, item, value, cleanup); err != nil {
+			errs = append(errs, fmt.Errorf("container: closing '%s': %w", item.key, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func closeItem(ctx context.Context, item *Item, value interface{}, cleanup func()) error {
+	var errs []error
+
+	if value != nil {
+		switch v := value.(type) {
+		case Stopper:
+			errs = append(errs, v.Stop(ctx))
+		case io.Closer:
+			errs = append(errs, v.Close())
+		}
+	}
+
+	for _, h := range item.onClose {
+		errs = append(errs, h.fn(ctx, value))
+	}
+
+	if cleanup != nil {
+		cleanup()
+	}
+
+	return errors.Join(errs...)
+}
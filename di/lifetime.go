@@ -0,0 +1,139 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// lifetime of an item.
+type lifetime int
+
+const (
+	singleton lifetime = iota
+	transient
+)
+
+func (l lifetime) String() string {
+	if l == transient {
+		return "transient"
+	}
+	return "singleton"
+}
+
+var (
+	// Singleton registers an item that is built once by Build and shared
+	// by all dependents. It is the default lifetime.
+	Singleton Option = func(o *options) {
+		o.lifetime = singleton
+	}
+
+	// Transient registers an item whose provider is called on each
+	// Get and each injection. A singleton can only depend on a transient
+	// item through a factory, see Provider.
+	Transient Option = func(o *options) {
+		o.lifetime = transient
+	}
+)
+
+// Provider is an injectable factory of T. A provider parameter of type
+// Provider[T] or func() (T, error) receives a factory that creates a new
+// instance of a transient T or returns the built singleton T on each call.
+type Provider[T any] func() (T, error)
+
+// factoryTarget returns T of a factory type func() (T, error).
+// Keep in sync with factoryTarget in internal/initgen.
+func factoryTarget(typ reflect.Type) (reflect.Type, bool) {
+	if typ.Kind() != reflect.Func ||
+		typ.IsVariadic() ||
+		typ.NumIn() != 0 ||
+		typ.NumOut() != 2 ||
+		typ.Out(1) != errorType {
+		return nil, false
+	}
+	return typ.Out(0), true
+}
+
+// lookupParam returns the item a single provider parameter depends on.
+// If no item is registered for the parameter type and the parameter
+// is a factory, the item created by the factory is returned.
+// Keep in sync with container.lookupParam in internal/initgen.
+func (c *Container) lookupParam(p param) (item *Item, factory bool, ok bool) {
+	if item, ok := c.lookup(p.key); ok {
+		return item, false, true
+	}
+
+	if target, isFactory := factoryTarget(p.key.typ); isFactory {
+		item, ok := c.lookup(key{typ: target, name: p.key.name})
+		return item, ok, ok
+	}
+
+	return nil, false, false
+}
+
+// factoryValue creates a factory of type typ for the item.
+func (c *Container) factoryValue(typ reflect.Type, item *Item) reflect.Value {
+	target := typ.Out(0)
+
+	return reflect.MakeFunc(typ, func([]reflect.Value) []reflect.Value {
+		value, err := c.itemValue(context.Background(), item, target)
+		if err != nil {
+			return []reflect.Value{reflect.Zero(target), reflect.ValueOf(&err).Elem()}
+		}
+		return []reflect.Value{convert(value, target), reflect.Zero(errorType)}
+	})
+}
+
+// convert a value assignable to typ to a value of type typ.
+func convert(value reflect.Value, typ reflect.Type) reflect.Value {
+	if value.Type() == typ {
+		return value
+	}
+	v := reflect.New(typ).Elem()
+	v.Set(value)
+	return v
+}
+
+// validateLifetimes checks that singletons do not depend on
+// transient items other than through a factory.
+// Keep in sync with container.validateLifetimes in internal/initgen.
+func (c *Container) validateLifetimes() error {
+	for _, item := range c.ordered() {
+		if item.lifetime == transient {
+			continue
+		}
+		for _, p := range item.params {
+			if err := c.validateCapture(item, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *Container) validateCapture(item *Item, p param) error {
+	var captured []*Item
+
+	switch {
+	case p.in:
+		for _, f := range p.fields {
+			if err := c.validateCapture(item, f); err != nil {
+				return err
+			}
+		}
+	case p.group != "":
+		captured, _ = c.groupMembers(p.group)
+	default:
+		if dep, factory, ok := c.lookupParam(p); ok && !factory {
+			captured = append(captured, dep)
+		}
+	}
+
+	for _, dep := range captured {
+		if dep.lifetime == transient {
+			return fmt.Errorf("Singleton '%s' depends on transient '%s', inject di.Provider[%s] instead", item.key, dep.key, dep.key.typ)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package di
+
+import "reflect"
+
+// Optional is a dependency that may have no provider.
+// A provider taking an Optional[T] parameter receives the built T
+// with OK set to true, or the zero value of T with OK set to false
+// when no provider of T exists.
+type Optional[T any] struct {
+	Value T
+	OK    bool
+}
+
+func (Optional[T]) optionalType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// optional is implemented by Optional.
+type optional interface {
+	optionalType() reflect.Type
+}
+
+var optionalInterface = reflect.TypeOf((*optional)(nil)).Elem()
+
+// optionalElem returns the type wrapped by an Optional type.
+func optionalElem(typ reflect.Type) (reflect.Type, bool) {
+	// A pointer to Optional also implements optional through its value methods.
+	if typ.Kind() != reflect.Struct || !typ.Implements(optionalInterface) {
+		return nil, false
+	}
+	return reflect.Zero(typ).Interface().(optional).optionalType(), true
+}
+
+// optionalValue wraps an item value into an Optional of type typ.
+func optionalValue(typ reflect.Type, value reflect.Value, ok bool) reflect.Value {
+	v := reflect.New(typ).Elem()
+	if ok {
+		v.Field(0).Set(value)
+		v.Field(1).SetBool(true)
+	}
+	return v
+}
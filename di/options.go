@@ -0,0 +1,167 @@
+package di
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+// An Option configures a provider registration.
+type Option func(*options)
+
+type options struct {
+	group     string
+	paramTags []string
+	onStart   []hook
+	onClose   []hook
+	lifetime  lifetime
+}
+
+// hook is a lifecycle hook of an item.
+type hook struct {
+	typ reflect.Type
+	fn  func(context.Context, interface{}) error
+}
+
+func newHook[T any](fn func(context.Context, T) error) hook {
+	return hook{
+		typ: reflect.TypeOf((*T)(nil)).Elem(),
+		fn: func(ctx context.Context, value interface{}) error {
+			v, _ := value.(T)
+			return fn(ctx, v)
+		},
+	}
+}
+
+// OnStart registers a hook that is called with the built item on Container.Start.
+func OnStart[T any](fn func(context.Context, T) error) Option {
+	return func(o *options) {
+		o.onStart = append(o.onStart, newHook(fn))
+	}
+}
+
+// OnClose registers a cleanup hook that is called with the built item on Container.Close.
+func OnClose[T any](fn func(context.Context, T) error) Option {
+	return func(o *options) {
+		o.onClose = append(o.onClose, newHook(fn))
+	}
+}
+
+// Group adds the item to a value group.
+// Items of a group are injected together into a slice parameter
+// tagged with `group:"name"`, in registration order.
+// A grouped item can only be resolved through its group.
+func Group(name string) Option {
+	return func(o *options) {
+		o.group = name
+	}
+}
+
+// ParamTags annotates the positional parameters of a provider
+// with struct tags. A parameter tagged with `name:"replica"`
+// depends on the item registered with RegisterNamed under that name.
+// A slice parameter tagged with `group:"handlers"` depends on
+// all items of the group. A parameter tagged with `optional:"true"`
+// receives the zero value when no provider exists, as does
+// a parameter of type Optional[T].
+// An empty tag leaves the parameter unannotated.
+func ParamTags(tags ...string) Option {
+	return func(o *options) {
+		o.paramTags = tags
+	}
+}
+
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// hooks validates the lifecycle hooks for the provided type.
+func (o options) hooks(typ reflect.Type) (onStart, onClose []hook) {
+	for _, h := range append(o.onStart, o.onClose...) {
+		if !typ.AssignableTo(h.typ) {
+			panic(fmt.Errorf("container: hook for type '%s' cannot be used with provided type '%s'", h.typ, typ))
+		}
+	}
+	return o.onStart, o.onClose
+}
+
+// params returns the dependencies of provider parameters.
+// Keep in sync with params in internal/initgen.
+func (o options) params(providerType reflect.Type) []param {
+	if len(o.paramTags) > providerType.NumIn() {
+		panic(fmt.Errorf("container: provider has %d parameters but %d param tags", providerType.NumIn(), len(o.paramTags)))
+	}
+
+	params := make([]param, providerType.NumIn())
+	for i := range params {
+		typ := providerType.In(i)
+		switch {
+		case embeds(typ, inType):
+			if i < len(o.paramTags) && o.paramTags[i] != "" {
+				panic(fmt.Errorf("container: parameter struct '%s' cannot be tagged", typ))
+			}
+			params[i] = newInParam(typ)
+		case i < len(o.paramTags):
+			params[i] = newParam(typ, reflect.StructTag(o.paramTags[i]))
+		default:
+			params[i] = newParam(typ, "")
+		}
+	}
+
+	return params
+}
+
+// param is a dependency of a provider parameter
+// or a field of a parameter struct.
+type param struct {
+	key      key
+	group    string
+	optional bool
+
+	// in reports whether the parameter is a struct embedding In.
+	in     bool
+	fields []param
+	// field is the index of the field in the parameter struct.
+	field int
+	// wrapped is the Optional type wrapping the dependency.
+	wrapped reflect.Type
+	// ctx reports whether the parameter receives the build context.
+	ctx bool
+}
+
+// newParam creates a parameter tagged with tag.
+// Keep in sync with newParam in internal/initgen.
+func newParam(typ reflect.Type, tag reflect.StructTag) param {
+	p := param{
+		key: key{
+			typ:  typ,
+			name: tag.Get("name"),
+		},
+		group:    tag.Get("group"),
+		optional: tag.Get("optional") == "true",
+	}
+
+	// The context parameter receives the build context.
+	p.ctx = typ == contextType && p.key.name == "" && p.group == ""
+
+	if elem, ok := optionalElem(typ); ok {
+		p.key.typ = elem
+		p.optional = true
+		p.wrapped = typ
+	}
+
+	if p.group != "" {
+		if p.key.name != "" {
+			panic(fmt.Errorf("container: parameter of type '%s' cannot be both named and grouped", typ))
+		}
+		if typ.Kind() != reflect.Slice {
+			panic(fmt.Errorf("container: group parameter of type '%s' must be a slice", typ))
+		}
+	}
+
+	return p
+}
@@ -0,0 +1,127 @@
+package di
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// BuildOption configures Build.
+type BuildOption func(*buildOptions)
+
+type buildOptions struct {
+	workers int
+}
+
+// Parallel builds independent items concurrently with at most n providers
+// running at a time. An item is scheduled as soon as its dependencies are built.
+// On the first error no further providers are started. The providers already
+// running are not canceled, their errors are joined with the first error.
+func Parallel(n int) BuildOption {
+	if n < 1 {
+		panic("container: parallelism must be at least 1")
+	}
+	return func(o *buildOptions) {
+		o.workers = n
+	}
+}
+
+// buildParallel builds the singleton items with a bounded
+// number of workers in dependency order. Providers receive ctx,
+// which stays valid after Build returns.
+func (c *Container) buildParallel(ctx context.Context, items []*Item, workers int) error {
+	c.mu.RLock()
+	pending := c.deps.OutDegrees()
+	dependents := make(map[*Item][]*Item, len(items))
+	for _, item := range items {
+		dependents[item] = c.deps.Dependents(item)
+	}
+	c.mu.RUnlock()
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		errs []error
+		done int
+		sem  = make(chan struct{}, workers)
+
+		// stop is closed on the first error to stop scheduling providers.
+		stop     = make(chan struct{})
+		stopOnce sync.Once
+	)
+
+	stopped := func() bool {
+		select {
+		case <-stop:
+			return true
+		default:
+			return ctx.Err() != nil
+		}
+	}
+
+	var schedule func(item *Item)
+	schedule = func(item *Item) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+			case <-stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+			defer func() { <-sem }()
+
+			if stopped() {
+				return
+			}
+
+			if item.lifetime != transient {
+				if _, err := c.build(ctx, item); err != nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+					stopOnce.Do(func() { close(stop) })
+					return
+				}
+			}
+
+			var ready []*Item
+			mu.Lock()
+			done++
+			for _, d := range dependents[item] {
+				pending[d]--
+				if pending[d] == 0 {
+					ready = append(ready, d)
+				}
+			}
+			mu.Unlock()
+
+			for _, d := range ready {
+				schedule(d)
+			}
+		}()
+	}
+
+	var roots []*Item
+	for _, item := range items {
+		if pending[item] == 0 {
+			roots = append(roots, item)
+		}
+	}
+
+	for _, item := range roots {
+		schedule(item)
+	}
+
+	wg.Wait()
+
+	if len(errs) == 0 && done < len(items) {
+		// The context was done before all providers were started.
+		return ctx.Err()
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,66 @@
+package di
+
+import "reflect"
+
+// NewChildContainer creates a container that inherits the items of parent.
+// Dependencies are looked up in the child first and then up the scope chain,
+// so providers registered in the child may depend on parent items and
+// override them. Groups contain the parent members followed by the child members.
+//
+// The parent must be resolved before the child is resolved and built before
+// the child is built. The child builds and closes only its own items.
+func NewChildContainer(parent *Container) *Container {
+	c := NewContainer()
+	c.parent = parent
+	return c
+}
+
+// Scope creates a child container, e.g. for the lifetime of a request.
+func (c *Container) Scope() *Container {
+	return NewChildContainer(c)
+}
+
+// RegisterValue registers an already constructed value as an item
+// of the dynamic type of value.
+func (c *Container) RegisterValue(value interface{}, opts ...Option) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		panic("container: value must not be nil")
+	}
+
+	provider := reflect.MakeFunc(
+		reflect.FuncOf(nil, []reflect.Type{v.Type()}, false),
+		func([]reflect.Value) []reflect.Value {
+			return []reflect.Value{v}
+		},
+	)
+
+	c.Register(provider.Interface(), opts...)
+}
+
+// groupMembers returns the members of a group in the scope chain.
+func (c *Container) groupMembers(group string) ([]*Item, bool) {
+	var members []*Item
+	var found bool
+
+	if c.parent != nil {
+		members, found = c.parent.groupMembers(group)
+	}
+
+	c.mu.RLock()
+	own, ok := c.groups[group]
+	c.mu.RUnlock()
+
+	if ok {
+		members = append(members[:len(members):len(members)], own...)
+		found = true
+	}
+
+	return members, found
+}
+
+// owns reports whether the item is registered in this container
+// and not in a parent.
+func (c *Container) owns(item *Item) bool {
+	return item.owner == c
+}
@@ -8,9 +8,9 @@ import (
 )
 
 func main() {
-	s, err := example.InitMyService()
+	injector, err := example.NewInjector()
 	if err != nil {
 		log.Fatal(err)
 	}
-	fmt.Println(s.Greetings())
+	fmt.Println(injector.MyService.Greetings())
 }
@@ -5,6 +5,7 @@ package example
 import (
 	"fmt"
 
+	"github.com/mgnsk/di-container/di"
 	"github.com/mgnsk/di-container/example/constants"
 )
 
@@ -70,12 +71,16 @@ func (build builder) build() (*MyService, error) {
 	return s, nil
 }
 
-func newGreeter(s mySentence) (greeter, error) {
-	return newMyGreeter(s)
+type myServiceParams struct {
+	di.In
+
+	Greeter greeter
+	Factory factory
+	Mult    constants.MyMultiplier
 }
 
-func newMyServiceProvider(g greeter, f factory, mult constants.MyMultiplier) (*MyService, error) {
-	return newMyService(g, f).withMultiplier(mult).build()
+func newMyServiceProvider(p myServiceParams) (*MyService, error) {
+	return newMyService(p.Greeter, p.Factory).withMultiplier(p.Mult).build()
 }
 
 func (s *MyService) Greetings() string {
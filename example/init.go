@@ -5,41 +5,119 @@ import (
 	"github.com/mgnsk/di-container/example/constants"
 )
 
+// Injector holds the singletons built by NewInjector.
+type Injector struct {
+	MyInt        constants.MyInt
+	MyMultiplier constants.MyMultiplier
+	mySentence   mySentence
+	mygreeter    mygreeter
+	factory      factory
+	MyService    *MyService
+	greeter      greeter
+}
+
+// NewInjector builds each singleton once, in dependency order.
+func NewInjector() (*Injector, error) {
+	myInt := constants.NewMyInt()
+	myMultiplier := constants.NewMyMultiplier()
+	mySentenceVal := newMySentence(myInt, myMultiplier)
+	mygreeterVal, err := newMyGreeter(mySentenceVal)
+	if err != nil {
+		return nil, err
+	}
+	factoryVal := newFactory()
+	myServiceParamsVal := myServiceParams{Greeter: mygreeterVal, Factory: factoryVal, Mult: myMultiplier}
+	myService, err := newMyServiceProvider(myServiceParamsVal)
+	if err != nil {
+		return nil, err
+	}
+	return &Injector{
+		MyInt:        myInt,
+		MyMultiplier: myMultiplier,
+		mySentence:   mySentenceVal,
+		mygreeter:    mygreeterVal,
+		factory:      factoryVal,
+		MyService:    myService,
+		greeter:      mygreeterVal,
+	}, nil
+}
+
 func InitMyInt() constants.MyInt {
-	MyInt := constants.NewMyInt()
-	return MyInt
+	myInt := constants.NewMyInt()
+	return myInt
 }
 
 func InitMyMultiplier() constants.MyMultiplier {
-	MyMultiplier := constants.NewMyMultiplier()
-	return MyMultiplier
+	myMultiplier := constants.NewMyMultiplier()
+	return myMultiplier
 }
 
 func initmySentence() mySentence {
-	MyInt := InitMyInt()
-	MyMultiplier := InitMyMultiplier()
-	mySentence := newMySentence(MyInt, MyMultiplier)
-	return mySentence
+	myInt := constants.NewMyInt()
+	myMultiplier := constants.NewMyMultiplier()
+	mySentenceVal := newMySentence(myInt, myMultiplier)
+	return mySentenceVal
 }
 
-func initgreeter() (greeter, error) {
-	mySentence := initmySentence()
-	greeter, err := newGreeter(mySentence)
-	if err != nil { return nil, err }
-	return greeter, nil
+func initmygreeter() (mygreeter, error) {
+	myInt := constants.NewMyInt()
+	myMultiplier := constants.NewMyMultiplier()
+	mySentenceVal := newMySentence(myInt, myMultiplier)
+	mygreeterVal, err := newMyGreeter(mySentenceVal)
+	if err != nil {
+		return mygreeter{}, err
+	}
+	return mygreeterVal, nil
 }
 
 func initfactory() factory {
-	factory := newFactory()
-	return factory
+	factoryVal := newFactory()
+	return factoryVal
 }
 
 func InitMyService() (*MyService, error) {
-	greeter, err := initgreeter()
-	if err != nil { return nil, err }
-	factory := initfactory()
-	MyMultiplier := InitMyMultiplier()
-	MyService, err := newMyServiceProvider(greeter, factory, MyMultiplier)
-	if err != nil { return nil, err }
-	return MyService, nil
+	myInt := constants.NewMyInt()
+	myMultiplier := constants.NewMyMultiplier()
+	mySentenceVal := newMySentence(myInt, myMultiplier)
+	mygreeterVal, err := newMyGreeter(mySentenceVal)
+	if err != nil {
+		return nil, err
+	}
+	factoryVal := newFactory()
+	myServiceParamsVal := myServiceParams{Greeter: mygreeterVal, Factory: factoryVal, Mult: myMultiplier}
+	myService, err := newMyServiceProvider(myServiceParamsVal)
+	if err != nil {
+		return nil, err
+	}
+	return myService, nil
+}
+
+func initgreeter() (greeter, error) {
+	myInt := constants.NewMyInt()
+	myMultiplier := constants.NewMyMultiplier()
+	mySentenceVal := newMySentence(myInt, myMultiplier)
+	mygreeterVal, err := newMyGreeter(mySentenceVal)
+	if err != nil {
+		return nil, err
+	}
+	return mygreeterVal, nil
+}
+
+// SentenceInjector holds the singletons built by NewSentenceInjector.
+type SentenceInjector struct {
+	MyInt        constants.MyInt
+	MyMultiplier constants.MyMultiplier
+	mySentence   mySentence
+}
+
+// NewSentenceInjector builds each singleton once, in dependency order.
+func NewSentenceInjector() *SentenceInjector {
+	myInt := constants.NewMyInt()
+	myMultiplier := constants.NewMyMultiplier()
+	mySentenceVal := newMySentence(myInt, myMultiplier)
+	return &SentenceInjector{
+		MyInt:        myInt,
+		MyMultiplier: myMultiplier,
+		mySentence:   mySentenceVal,
+	}
 }
@@ -7,14 +7,21 @@ import (
 	"github.com/mgnsk/di-container/example/constants"
 )
 
-// Generate registers a container for code generation.
+// Generate registers the containers for code generation.
 func Generate() {
 	di.Generate(func(c *di.Container) {
-		c.Register(newGreeter)
+		c.Register(newMyGreeter)
+		c.Bind((*greeter)(nil), (*mygreeter)(nil))
 		c.Register(newMySentence)
 		c.Register(constants.NewMyMultiplier)
 		c.Register(newMyServiceProvider)
 		c.Register(constants.NewMyInt)
 		c.Register(newFactory)
+	}, di.Initializers)
+
+	di.GenerateNamed("Sentence", func(c *di.Container) {
+		c.Register(newMySentence)
+		c.Register(constants.NewMyMultiplier)
+		c.Register(constants.NewMyInt)
 	})
 }
@@ -1,9 +1,15 @@
 module github.com/mgnsk/di-container
 
-go 1.14
+go 1.22.0
 
 require (
-	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/moznion/gowrtr v1.7.0
-	golang.org/x/tools v0.6.0
+	golang.org/x/tools v0.26.0
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	golang.org/x/mod v0.21.0 // indirect
+	golang.org/x/sync v0.8.0 // indirect
 )
@@ -0,0 +1,51 @@
+package diff
+
+import "testing"
+
+func TestUnifiedEqual(t *testing.T) {
+	if d := Unified("a", "b", []byte("x\ny\n"), []byte("x\ny\n")); d != "" {
+		t.Fatalf("expected no diff, got:\n%s", d)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n14\n15\n"
+	b := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\n11\n12\n13\n15\n16\n"
+
+	expected := `--- old
++++ new
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+@@ -11,5 +11,5 @@
+ 11
+ 12
+ 13
+-14
+ 15
++16
+`
+
+	if d := Unified("old", "new", []byte(a), []byte(b)); d != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, d)
+	}
+}
+
+func TestUnifiedNewFile(t *testing.T) {
+	expected := `--- old
++++ new
+@@ -0,0 +1,2 @@
++a
++b
+`
+
+	if d := Unified("old", "new", nil, []byte("a\nb\n")); d != expected {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, d)
+	}
+}
@@ -0,0 +1,374 @@
+package initgen
+
+import (
+	"errors"
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+
+	"github.com/mgnsk/di-container/dag"
+)
+
+// lifetime of an item.
+type lifetime int
+
+const (
+	singleton lifetime = iota
+	transient
+)
+
+func (l lifetime) String() string {
+	if l == transient {
+		return "transient"
+	}
+	return "singleton"
+}
+
+type options struct {
+	transient bool
+	group     string
+	paramTags []string
+	// hooks are the types of the lifecycle hooks.
+	hooks []types.Type
+}
+
+// key identifies an item by the string of its type and its name.
+type key struct {
+	typ  string
+	name string
+}
+
+func newKey(typ types.Type, name string) key {
+	return key{typ: types.TypeString(typ, nil), name: name}
+}
+
+// keyString formats a type and a name like the keys of di.Container.
+func keyString(typ types.Type, name string) string {
+	if name != "" {
+		return fmt.Sprintf("%s name=%q", typeString(typ), name)
+	}
+	return typeString(typ)
+}
+
+// item is a registered provider or a field of a result struct.
+type item struct {
+	typ      types.Type
+	name     string
+	group    string
+	provider *types.Func
+	// index of the registration.
+	index    int
+	params   []param
+	lifetime lifetime
+	// field of the result struct providing the item.
+	field string
+
+	returnsCleanup bool
+	returnsErr     bool
+}
+
+func (it *item) key() key {
+	return newKey(it.typ, it.name)
+}
+
+func (it *item) String() string {
+	return keyString(it.typ, it.name)
+}
+
+// param is a dependency of a provider parameter
+// or a field of a parameter struct.
+type param struct {
+	typ      types.Type
+	name     string
+	group    string
+	optional bool
+
+	// in reports whether the parameter is a struct embedding In.
+	in     bool
+	fields []param
+	// field is the name of the field in the parameter struct.
+	field string
+	// wrapped reports whether the dependency is wrapped in Optional.
+	wrapped bool
+	// ctx reports whether the parameter receives the context.
+	ctx bool
+}
+
+func (p param) key() key {
+	return newKey(p.typ, p.name)
+}
+
+func (p param) String() string {
+	return keyString(p.typ, p.name)
+}
+
+type binding struct {
+	iface    types.Type
+	concrete types.Type
+}
+
+// container is the static counterpart of di.Container. Its registration
+// and resolution rules must stay in sync with the di package, the packages
+// in testdata/errors check that both report the same errors.
+type container struct {
+	// name of the injector, empty for di.Generate.
+	name     string
+	items    map[key]*item
+	aliases  map[key]*item
+	groups   map[string][]*item
+	bindings []binding
+	deps     *dag.Graph[*item, struct{}]
+	// order of the items after resolve.
+	order []*item
+	// all items in registration order.
+	all []*item
+
+	initializers bool
+}
+
+func newContainer() *container {
+	return &container{
+		items:   make(map[key]*item),
+		aliases: make(map[key]*item),
+		groups:  make(map[string][]*item),
+		deps:    dag.New[*item, struct{}](),
+	}
+}
+
+// add an item to the container. Keep in sync with di.Container.add.
+func (c *container) add(it *item) error {
+	if _, ok := c.items[it.key()]; ok && it.group == "" {
+		return fmt.Errorf("item type '%s' is already registered", it)
+	}
+
+	if it.group != "" {
+		c.groups[it.group] = append(c.groups[it.group], it)
+	} else {
+		c.items[it.key()] = it
+	}
+	c.deps.Add(it, struct{}{})
+	c.all = append(c.all, it)
+
+	return nil
+}
+
+// addOut adds each field of a struct embedding Out as a separate item.
+// Keep in sync with di.Container.registerOut.
+func (c *container) addOut(it *item) error {
+	st := it.typ.Underlying().(*types.Struct)
+
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if f.Embedded() && isNamed(f.Type(), diImport, "Out") {
+			continue
+		}
+		if !f.Exported() {
+			return fmt.Errorf("field '%s' of result struct '%s' must be exported", f.Name(), typeString(it.typ))
+		}
+
+		tag := reflect.StructTag(st.Tag(i))
+		fieldItem := &item{
+			typ:      f.Type(),
+			name:     tag.Get("name"),
+			group:    tag.Get("group"),
+			index:    it.index,
+			params:   []param{{typ: it.typ}},
+			lifetime: it.lifetime,
+			field:    f.Name(),
+		}
+
+		if fieldItem.name != "" && fieldItem.group != "" {
+			return fmt.Errorf("field '%s' of result struct '%s' cannot be both named and grouped", f.Name(), typeString(it.typ))
+		}
+
+		if err := c.add(fieldItem); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *container) lookup(k key) (*item, bool) {
+	if it, ok := c.items[k]; ok {
+		return it, true
+	}
+	it, ok := c.aliases[k]
+	return it, ok
+}
+
+// lookupParam returns the item of a parameter, or the item created by
+// a factory parameter. Keep in sync with di.Container.lookupParam.
+func (c *container) lookupParam(p param) (it *item, factory bool, ok bool) {
+	if it, ok := c.lookup(p.key()); ok {
+		return it, false, true
+	}
+
+	if target, isFactory := factoryTarget(p.typ); isFactory {
+		it, ok := c.lookup(newKey(target, p.name))
+		return it, ok, ok
+	}
+
+	return nil, false, false
+}
+
+// resolveBindings aliases the bound interfaces to their items.
+// Keep in sync with di.Container.resolveBindings.
+func (c *container) resolveBindings() error {
+	for _, b := range c.bindings {
+		k := newKey(b.iface, "")
+		if _, provided := c.items[k]; provided {
+			return fmt.Errorf("Ambiguous binding for type '%s': type already has a provider", typeString(b.iface))
+		}
+
+		it, ok := c.items[newKey(b.concrete, "")]
+		if !ok {
+			return fmt.Errorf("Missing provider for type '%s' required by '%s'", typeString(b.concrete), typeString(b.iface))
+		}
+		if !types.AssignableTo(b.concrete, b.iface) {
+			return fmt.Errorf("Type '%s' does not implement '%s'", typeString(b.concrete), typeString(b.iface))
+		}
+
+		if alias, ok := c.aliases[k]; ok && alias != it {
+			return fmt.Errorf("Ambiguous binding for type '%s': bound to both '%s' and '%s'", typeString(b.iface), alias, typeString(b.concrete))
+		}
+
+		c.aliases[k] = it
+	}
+
+	return nil
+}
+
+// resolve the dependencies of the items and sort them in dependency order.
+// Keep in sync with di.Container.Resolve.
+func (c *container) resolve() error {
+	if err := c.resolveBindings(); err != nil {
+		return err
+	}
+
+	// All missing dependencies are reported at once in registration order.
+	var errs []error
+	for _, it := range c.all {
+		for _, p := range it.params {
+			deps, err := c.resolveParam(it.typ, p)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			for _, dep := range deps {
+				c.deps.AddEdge(it, dep)
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if err := c.validateLifetimes(); err != nil {
+		return err
+	}
+
+	order, err := c.deps.Sort()
+	if err != nil {
+		var cycle *dag.CycleError[*item]
+		if errors.As(err, &cycle) {
+			path := make([]string, 0, len(cycle.Path))
+			for _, it := range cycle.Path {
+				path = append(path, typeString(it.typ))
+			}
+			return fmt.Errorf("Dependency cycle detected: %s", strings.Join(path, " -> "))
+		}
+		return err
+	}
+	c.order = order
+
+	return nil
+}
+
+// resolveParam returns the items a parameter of requiredBy depends on.
+// Keep in sync with di.Container.resolveParam.
+func (c *container) resolveParam(requiredBy types.Type, p param) ([]*item, error) {
+	if p.ctx {
+		return nil, nil
+	}
+
+	if p.in {
+		var items []*item
+		var errs []error
+		for _, f := range p.fields {
+			fieldItems, err := c.resolveParam(requiredBy, f)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			items = append(items, fieldItems...)
+		}
+		return items, errors.Join(errs...)
+	}
+
+	if p.group != "" {
+		members := c.groups[p.group]
+		elem := p.typ.Underlying().(*types.Slice).Elem()
+		for _, member := range members {
+			if !types.AssignableTo(member.typ, elem) {
+				return nil, fmt.Errorf("Type '%s' of group '%s' is not assignable to '%s'", typeString(member.typ), p.group, typeString(elem))
+			}
+		}
+		return members, nil
+	}
+
+	if it, _, ok := c.lookupParam(p); ok {
+		return []*item{it}, nil
+	}
+
+	if p.optional {
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("Missing provider for type '%s' required by '%s'", p, typeString(requiredBy))
+}
+
+// validateLifetimes reports a singleton capturing a transient item.
+// Keep in sync with di.Container.validateLifetimes and validateCapture.
+func (c *container) validateLifetimes() error {
+	for _, it := range c.all {
+		if it.lifetime == transient {
+			continue
+		}
+		for _, p := range it.params {
+			if err := c.validateCapture(it, p); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *container) validateCapture(it *item, p param) error {
+	var captured []*item
+
+	switch {
+	case p.in:
+		for _, f := range p.fields {
+			if err := c.validateCapture(it, f); err != nil {
+				return err
+			}
+		}
+	case p.group != "":
+		captured = c.groups[p.group]
+	default:
+		if dep, factory, ok := c.lookupParam(p); ok && !factory {
+			captured = append(captured, dep)
+		}
+	}
+
+	for _, dep := range captured {
+		if dep.lifetime == transient {
+			return fmt.Errorf("Singleton '%s' depends on transient '%s', inject di.Provider[%s] instead", it, dep, typeString(dep.typ))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,624 @@
+package initgen
+
+import (
+	"fmt"
+	"go/token"
+	"go/types"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/moznion/gowrtr/generator"
+)
+
+// codegen holds the state shared by the generated functions of a container.
+type codegen struct {
+	c   *container
+	pkg *types.Package
+	// reserved names that variables must not shadow.
+	reserved map[string]bool
+	// used imports by path.
+	used map[string]bool
+	// declared package level names of the generated file.
+	declared map[string]bool
+	// err is the first error of the generated code.
+	err error
+}
+
+func newCodegen(c *container, pkg *types.Package, declared map[string]bool) *codegen {
+	g := &codegen{
+		c:        c,
+		pkg:      pkg,
+		reserved: map[string]bool{"ctx": true, "err": true, "di": true, "context": true},
+		used:     make(map[string]bool),
+		declared: declared,
+	}
+
+	for _, name := range types.Universe.Names() {
+		g.reserved[name] = true
+	}
+	for _, name := range pkg.Scope().Names() {
+		g.reserved[name] = true
+	}
+	// Variables must not shadow a package the generated code may qualify.
+	for _, it := range c.all {
+		g.reservePackages(it.typ)
+		if it.provider != nil {
+			g.reserved[it.provider.Pkg().Name()] = true
+		}
+		for _, p := range it.params {
+			g.reserveParam(p)
+		}
+	}
+	for _, b := range c.bindings {
+		g.reservePackages(b.iface)
+	}
+
+	return g
+}
+
+func (g *codegen) reserveParam(p param) {
+	g.reservePackages(p.typ)
+	for _, f := range p.fields {
+		g.reserveParam(f)
+	}
+}
+
+// reservePackages reserves the names of the packages of the named types in typ.
+func (g *codegen) reservePackages(typ types.Type) {
+	switch t := typ.(type) {
+	case *types.Named:
+		if pkg := t.Obj().Pkg(); pkg != nil {
+			g.reserved[pkg.Name()] = true
+		}
+		for i := 0; i < t.TypeArgs().Len(); i++ {
+			g.reservePackages(t.TypeArgs().At(i))
+		}
+	case *types.Pointer:
+		g.reservePackages(t.Elem())
+	case *types.Slice:
+		g.reservePackages(t.Elem())
+	case *types.Array:
+		g.reservePackages(t.Elem())
+	case *types.Chan:
+		g.reservePackages(t.Elem())
+	case *types.Map:
+		g.reservePackages(t.Key())
+		g.reservePackages(t.Elem())
+	case *types.Signature:
+		for _, tuple := range []*types.Tuple{t.Params(), t.Results()} {
+			for i := 0; i < tuple.Len(); i++ {
+				g.reservePackages(tuple.At(i).Type())
+			}
+		}
+	case *types.Struct:
+		for i := 0; i < t.NumFields(); i++ {
+			g.reservePackages(t.Field(i).Type())
+		}
+	case *types.Interface:
+		for i := 0; i < t.NumMethods(); i++ {
+			g.reservePackages(t.Method(i).Type())
+		}
+	}
+}
+
+// qualifier imports the package of a type written by the generated code.
+func (g *codegen) qualifier(pkg *types.Package) string {
+	if pkg == g.pkg {
+		return ""
+	}
+	g.used[pkg.Path()] = true
+	return pkg.Name()
+}
+
+// typeName of typ in the generated package.
+func (g *codegen) typeName(typ types.Type) string {
+	return types.TypeString(typ, g.qualifier)
+}
+
+// zero value of typ in the generated package.
+func (g *codegen) zero(typ types.Type) string {
+	switch t := typ.Underlying().(type) {
+	case *types.Basic:
+		switch {
+		case t.Info()&types.IsBoolean != 0:
+			return "false"
+		case t.Info()&types.IsString != 0:
+			return `""`
+		case t.Kind() == types.UnsafePointer:
+			return "nil"
+		default:
+			return "0"
+		}
+	case *types.Struct, *types.Array:
+		return g.typeName(typ) + "{}"
+	default:
+		return "nil"
+	}
+}
+
+// provider returns the qualified provider of the item.
+func (g *codegen) provider(it *item) string {
+	if q := g.qualifier(it.provider.Pkg()); q != "" {
+		return q + "." + it.provider.Name()
+	}
+	return it.provider.Name()
+}
+
+// imports used by the generated code.
+func (g *codegen) imports() []string {
+	imports := make([]string, 0, len(g.used))
+	for imp := range g.used {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+
+	return imports
+}
+
+// suffix distinguishes items of the same type.
+func (g *codegen) suffix(it *item) string {
+	if it.group != "" {
+		// Group members are suffixed with their position in the group.
+		for i, member := range g.c.groups[it.group] {
+			if member == it {
+				return camelCase(it.group) + strconv.Itoa(i)
+			}
+		}
+	}
+	return camelCase(it.name)
+}
+
+// declare a unique package level name that does not
+// conflict with the package scope or earlier declarations.
+func (g *codegen) declare(name string) string {
+	declName := name
+	for i := 2; g.declared[declName] || g.pkg.Scope().Lookup(declName) != nil; i++ {
+		declName = name + strconv.Itoa(i)
+	}
+	g.declared[declName] = true
+	return declName
+}
+
+// funcBody builds the statements of a generated function. Singletons are
+// assigned to a variable once and the variable is passed to all dependents.
+// It tracks the cleanup functions returned by providers so that they
+// can be called in reverse order when a later provider fails.
+type funcBody struct {
+	g     *codegen
+	vars  map[*item]string
+	names map[string]bool
+	// zero is returned with the error when a provider fails.
+	zero string
+	// returnsCleanup reports whether the function returns a cleanup function.
+	returnsCleanup bool
+	// returnsErr reports whether any provider returns an error.
+	returnsErr bool
+	// needsCtx reports whether any provider takes the context.
+	needsCtx bool
+	cleanups []string
+	stmts    []string
+}
+
+func (g *codegen) newBody(zero string, returnsCleanup bool) *funcBody {
+	b := &funcBody{
+		g:              g,
+		vars:           make(map[*item]string),
+		names:          make(map[string]bool),
+		zero:           zero,
+		returnsCleanup: returnsCleanup,
+	}
+	for name := range g.reserved {
+		b.names[name] = true
+	}
+	return b
+}
+
+// declare a unique variable name.
+func (b *funcBody) declare(name string) string {
+	varName := name
+	for i := 2; b.names[varName]; i++ {
+		varName = name + strconv.Itoa(i)
+	}
+	b.names[varName] = true
+	return varName
+}
+
+func (b *funcBody) add(format string, args ...interface{}) {
+	b.stmts = append(b.stmts, fmt.Sprintf(format, args...))
+}
+
+// assign the result of call to varName.
+func (b *funcBody) assign(varName, call string, returnsCleanup, returnsErr bool) {
+	lhs := []string{varName}
+	var cleanup string
+	if returnsCleanup {
+		cleanup = b.declare("cleanup")
+		lhs = append(lhs, cleanup)
+	}
+	if returnsErr {
+		lhs = append(lhs, "err")
+		b.returnsErr = true
+	}
+
+	b.add("%s := %s", strings.Join(lhs, ", "), call)
+
+	if returnsErr {
+		results := []string{b.zero}
+		if b.returnsCleanup {
+			results = append(results, "nil")
+		}
+		results = append(results, "err")
+
+		b.add("if err != nil {\n%sreturn %s\n}", b.cleanupCalls(), strings.Join(results, ", "))
+	}
+
+	// The cleanup of a failed provider is not called.
+	if cleanup != "" {
+		b.cleanups = append(b.cleanups, cleanup)
+	}
+}
+
+// cleanupCalls calls the cleanups in reverse order.
+func (b *funcBody) cleanupCalls() string {
+	var calls string
+	for i := len(b.cleanups) - 1; i >= 0; i-- {
+		calls += b.cleanups[i] + "()\n"
+	}
+	return calls
+}
+
+// ret returns the results and the cleanup and error if the function returns them.
+func (b *funcBody) ret(results ...string) {
+	if b.returnsCleanup {
+		results = append(results, fmt.Sprintf("func() {\n%s}", b.cleanupCalls()))
+	}
+	if b.returnsErr {
+		results = append(results, "nil")
+	}
+	b.add("return %s", strings.Join(results, ", "))
+}
+
+// value builds the item and returns the variable holding it.
+// A singleton is built once, a transient item on each use.
+func (b *funcBody) value(it *item) string {
+	if varName, ok := b.vars[it]; ok {
+		return varName
+	}
+
+	args := make([]string, 0, len(it.params))
+	for _, p := range it.params {
+		arg := b.param(p)
+		if arg == "" {
+			// A missing optional dependency.
+			arg = b.g.zero(p.typ)
+		}
+		args = append(args, arg)
+	}
+
+	base := baseName(it.typ)
+	varName := b.declare(safeVarName(lowerFirst(base)+b.g.suffix(it), base))
+
+	if it.field != "" {
+		// The item is a field of a result struct.
+		b.add("%s := %s.%s", varName, args[0], it.field)
+	} else {
+		call := fmt.Sprintf("%s(%s)", b.g.provider(it), strings.Join(args, ", "))
+		b.assign(varName, call, it.returnsCleanup, it.returnsErr)
+	}
+
+	if it.lifetime == singleton {
+		b.vars[it] = varName
+	}
+
+	return varName
+}
+
+// param builds a provider parameter and returns the argument to pass to the provider.
+// It returns an empty string for a missing optional dependency.
+func (b *funcBody) param(p param) string {
+	switch {
+	case p.ctx:
+		b.needsCtx = true
+		b.g.used["context"] = true
+		return "ctx"
+
+	case p.in:
+		var fields []string
+		for _, f := range p.fields {
+			// Missing optional fields are left unset.
+			if arg := b.param(f); arg != "" {
+				fields = append(fields, fmt.Sprintf("%s: %s", f.field, arg))
+			}
+		}
+
+		base := baseName(p.typ)
+		varName := b.declare(safeVarName(lowerFirst(base), base))
+		b.add("%s := %s{%s}", varName, b.g.typeName(p.typ), strings.Join(fields, ", "))
+
+		return varName
+
+	case p.group != "":
+		// Assemble the group slice in registration order.
+		members := b.g.c.groups[p.group]
+		values := make([]string, 0, len(members))
+		for _, member := range members {
+			values = append(values, b.value(member))
+		}
+
+		varName := b.declare(safeVarName(lowerFirst(camelCase(p.group)), ""))
+		b.add("%s := %s{%s}", varName, b.g.typeName(p.typ), strings.Join(values, ", "))
+
+		return varName
+	}
+
+	it, factory, ok := b.g.c.lookupParam(p)
+	if factory {
+		return b.factory(p.typ, it)
+	}
+
+	if p.wrapped {
+		optionalType := fmt.Sprintf("di.Optional[%s]", b.g.typeName(p.typ))
+		b.g.used[diImport] = true
+		if !ok {
+			return optionalType + "{}"
+		}
+		return fmt.Sprintf("%s{Value: %s, OK: true}", optionalType, b.value(it))
+	}
+
+	if !ok {
+		return ""
+	}
+
+	return b.value(it)
+}
+
+// factory returns a function literal building the item on each call.
+// Singletons built before the factory are captured by the closure.
+func (b *funcBody) factory(typ types.Type, it *item) string {
+	target, _ := factoryTarget(typ)
+
+	sub := b.g.newBody(b.g.zero(target), false)
+	for k, v := range b.vars {
+		sub.vars[k] = v
+	}
+	for k, v := range b.names {
+		sub.names[k] = v
+	}
+
+	varName := sub.value(it)
+	if len(sub.cleanups) > 0 && b.g.err == nil {
+		b.g.err = fmt.Errorf("initgen: factory of type '%s' with a cleanup function is not supported", typeString(target))
+	}
+	sub.add("return %s, nil", varName)
+
+	if sub.needsCtx {
+		// The closure captures the context.
+		b.needsCtx = true
+	}
+
+	return fmt.Sprintf("func() (%s, error) {\n%s\n}", b.g.typeName(target), strings.Join(sub.stmts, "\n"))
+}
+
+// function generates a function returning the results of build. The body is
+// built twice: first to find out whether the function returns a cleanup
+// function, which is returned on error along with zero.
+func (g *codegen) function(name, zero string, resultTypes []string, build func(b *funcBody)) *generator.Func {
+	first := g.newBody(zero, false)
+	build(first)
+
+	b := g.newBody(zero, len(first.cleanups) > 0)
+	build(b)
+
+	sig := generator.NewFuncSignature(name)
+	if b.needsCtx {
+		sig = sig.AddParameters(generator.NewFuncParameter("ctx", "context.Context"))
+	}
+	sig = sig.AddReturnTypes(resultTypes...)
+	if b.returnsCleanup {
+		sig = sig.AddReturnTypes("func()")
+	}
+	if b.returnsErr {
+		sig = sig.AddReturnTypes("error")
+	}
+
+	stmts := make([]generator.Statement, 0, len(b.stmts))
+	for _, stmt := range b.stmts {
+		stmts = append(stmts, generator.NewRawStatement(stmt))
+	}
+
+	return generator.NewFunc(nil, sig, stmts...)
+}
+
+// injectorField is a field of the injector struct.
+type injectorField struct {
+	name string
+	typ  types.Type
+	item *item
+}
+
+// injectorFields returns a field for each singleton and interface binding.
+func (g *codegen) injectorFields() []injectorField {
+	var fields []injectorField
+	names := make(map[string]bool)
+
+	addField := func(typ types.Type, it *item, suffix string) {
+		base := baseName(typ) + suffix
+		name := base
+		for i := 2; names[name]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+		names[name] = true
+		fields = append(fields, injectorField{name: name, typ: typ, item: it})
+	}
+
+	for _, it := range g.c.order {
+		if it.lifetime == singleton {
+			addField(it.typ, it, g.suffix(it))
+		}
+	}
+
+	for _, b := range g.c.bindings {
+		it := g.c.aliases[newKey(b.iface, "")]
+		if it.lifetime == singleton {
+			addField(b.iface, it, "")
+		}
+	}
+
+	return fields
+}
+
+// injector generates the injector struct holding every singleton and the
+// function building them in dependency order. The names are prefixed
+// with the name of the container.
+func (g *codegen) injector() []generator.Statement {
+	name := g.c.name + "Injector"
+	constructor := "New" + name
+	if !token.IsExported(name) {
+		constructor = "new" + camelCase(name)
+	}
+	g.declared[name] = true
+	g.declared[constructor] = true
+
+	fields := g.injectorFields()
+
+	st := generator.NewStruct(name)
+	for _, f := range fields {
+		st = st.AddField(f.name, g.typeName(f.typ))
+	}
+
+	newFunc := g.function(constructor, "nil", []string{"*" + name}, func(b *funcBody) {
+		for _, it := range g.c.order {
+			if it.lifetime == singleton {
+				b.value(it)
+			}
+		}
+
+		values := make([]string, 0, len(fields))
+		for _, f := range fields {
+			values = append(values, fmt.Sprintf("%s: %s", f.name, b.vars[f.item]))
+		}
+		b.ret(fmt.Sprintf("&%s{\n%s,\n}", name, strings.Join(values, ",\n")))
+	})
+
+	return []generator.Statement{
+		generator.NewCommentf(" %s holds the singletons built by %s.", name, constructor),
+		st,
+		generator.NewNewline(),
+		generator.NewCommentf(" %s builds each singleton once, in dependency order.", constructor),
+		newFunc,
+		generator.NewNewline(),
+	}
+}
+
+// initializer generates the initializer of a type. It builds the singletons
+// the type depends on once, in dependency order, and the type itself.
+// The name of the container follows the Init prefix.
+func (g *codegen) initializer(typ types.Type, it *item, suffix string) generator.Statement {
+	base := baseName(typ)
+	prefix := "init"
+	if unicode.IsUpper([]rune(base)[0]) {
+		prefix = "Init"
+	}
+
+	deps := make(map[*item]bool)
+	for _, dep := range g.c.deps.Descendants(it) {
+		deps[dep] = true
+	}
+
+	return g.function(g.declare(prefix+camelCase(g.c.name)+base+suffix), g.zero(typ), []string{g.typeName(typ)}, func(b *funcBody) {
+		for _, dep := range g.c.order {
+			if deps[dep] && dep.lifetime == singleton {
+				b.value(dep)
+			}
+		}
+		b.ret(b.value(it))
+	})
+}
+
+// initializers generates an initializer for each type and interface binding.
+func (g *codegen) initializers() []generator.Statement {
+	var stmts []generator.Statement
+
+	for _, it := range g.c.order {
+		stmts = append(stmts, g.initializer(it.typ, it, g.suffix(it)), generator.NewNewline())
+	}
+
+	for _, b := range g.c.bindings {
+		it := g.c.aliases[newKey(b.iface, "")]
+		stmts = append(stmts, g.initializer(b.iface, it, ""), generator.NewNewline())
+	}
+
+	return stmts
+}
+
+// baseName returns an identifier for typ without the package qualifier.
+func baseName(typ types.Type) string {
+	switch t := typ.(type) {
+	case *types.Named:
+		return t.Obj().Name()
+	case *types.Basic:
+		return t.Name()
+	case *types.Pointer:
+		return baseName(t.Elem())
+	case *types.Slice:
+		return baseName(t.Elem()) + "Slice"
+	case *types.Array:
+		return baseName(t.Elem()) + "Array"
+	case *types.Map:
+		return baseName(t.Key()) + baseName(t.Elem()) + "Map"
+	case *types.Chan:
+		return baseName(t.Elem()) + "Chan"
+	case *types.Signature:
+		return "Func"
+	case *types.Interface:
+		return "Interface"
+	case *types.Struct:
+		return "Struct"
+	default:
+		return "Value"
+	}
+}
+
+// safeVarName avoids shadowing the type name and keywords.
+func safeVarName(varName, typeName string) string {
+	if varName == typeName || token.IsKeyword(varName) {
+		varName += "Val"
+	}
+	return varName
+}
+
+// lowerFirst lowercases the leading word of an identifier,
+// keeping initialisms intact: DB -> db, HTTPServer -> httpServer.
+func lowerFirst(name string) string {
+	runes := []rune(name)
+	for i := range runes {
+		if !unicode.IsUpper(runes[i]) {
+			break
+		}
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// camelCase converts an item name to an identifier suffix.
+func camelCase(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
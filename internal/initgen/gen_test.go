@@ -0,0 +1,154 @@
+package initgen
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mgnsk/di-container/internal/initgen/testdata/features"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/initnames"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/initnames/a"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/injector"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/selfref"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/shadow"
+)
+
+var update = flag.Bool("update", false, "update the init.go files in testdata")
+
+// TestGenerateTestdata compares the code generated for each package in testdata with its init.go.
+func TestGenerateTestdata(t *testing.T) {
+	sources, err := filepath.Glob("testdata/*/initgen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, source := range sources {
+		dir := filepath.Dir(source)
+
+		t.Run(filepath.Base(dir), func(t *testing.T) {
+			t.Parallel()
+
+			files, err := Config{Dir: dir}.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if *update {
+				if err := os.WriteFile(files[0].Path, files[0].Src, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			expected, err := os.ReadFile(filepath.Join(dir, "init.go"))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(files[0].Src) != string(expected) {
+				t.Fatalf("expected:\n%s\ngot:\n%s", expected, files[0].Src)
+			}
+		})
+	}
+}
+
+func TestInjector(t *testing.T) {
+	injector.Events = nil
+
+	inj, cleanup, err := injector.NewInjector(injector.WithDSN(context.Background(), "dsn"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if inj.Store != inj.DB || inj.Store.Get() != "dsn" {
+		t.Fatal("expected the store to be the db")
+	}
+
+	first, err := inj.Server.NewRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := inj.Server.NewRequest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second || first.Store != inj.DB {
+		t.Fatal("expected new requests sharing the db")
+	}
+
+	cleanup()
+
+	expected := []string{"config", "db", "cache", "server", "request", "request", "close cache", "close db"}
+	if !reflect.DeepEqual(injector.Events, expected) {
+		t.Fatalf("expected %v, got %v", expected, injector.Events)
+	}
+}
+
+func TestInjectorError(t *testing.T) {
+	injector.Events = nil
+	injector.FailCache = true
+	defer func() { injector.FailCache = false }()
+
+	if _, _, err := injector.NewInjector(injector.WithDSN(context.Background(), "dsn")); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	// The cleanups of the providers built before the failure are called.
+	expected := []string{"config", "db", "close db"}
+	if !reflect.DeepEqual(injector.Events, expected) {
+		t.Fatalf("expected %v, got %v", expected, injector.Events)
+	}
+
+	if _, _, err := injector.NewInjector(context.Background()); err == nil {
+		t.Fatal("expected an error without a dsn")
+	}
+}
+
+func TestInjectorFeatures(t *testing.T) {
+	inj := features.NewInjector()
+	repo := inj.Repo
+
+	if repo.Params.Primary != inj.DBPrimary || repo.Replica != inj.DBReplica || repo.Replica.Name != "replica" {
+		t.Fatal("expected the named databases of the result struct")
+	}
+
+	var paths []string
+	for _, h := range repo.Params.Handlers {
+		paths = append(paths, h.Path())
+	}
+	if !reflect.DeepEqual(paths, []string{"/users", "/health"}) {
+		t.Fatalf("expected the handlers group in registration order, got %v", paths)
+	}
+
+	if repo.Params.Logger != nil || repo.Logger != nil || repo.Cache.OK {
+		t.Fatal("expected missing optional dependencies")
+	}
+
+	if repo.Port != 8080 {
+		t.Fatal("expected the named optional port")
+	}
+}
+
+func TestInitializerNames(t *testing.T) {
+	// Conflicting initializers are numbered after the InitConfig of the package.
+	var _ a.Config = initnames.InitConfig2()
+	if initnames.InitConfig3() == nil {
+		t.Fatal("expected InitConfig3 to build *b.Config")
+	}
+}
+
+func TestInjectorSelfReference(t *testing.T) {
+	if selfref.Name() != "service" {
+		t.Fatal("expected the service of the injector")
+	}
+}
+
+func TestInjectorPackageNames(t *testing.T) {
+	// The config variable must not shadow the config package.
+	cfg, err := shadow.InitConfig()
+	if err != nil || cfg.Addr != ":8080" {
+		t.Fatalf("expected the config, got %v: %v", cfg, err)
+	}
+}
@@ -0,0 +1,346 @@
+package initgen
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/types"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/mgnsk/di-container/internal/render"
+	"github.com/moznion/gowrtr/generator"
+	"golang.org/x/tools/go/packages"
+)
+
+// Config configures the generator.
+type Config struct {
+	// Dir is the directory the package patterns are relative to.
+	// The current working dir is used if empty.
+	Dir string
+	// Source is the name of the file with the di.Generate call, initgen.go by default.
+	Source string
+	// Output is the path of the generated file, relative to the package dir
+	// unless absolute, init.go by default. An absolute path can only be used
+	// with a single package.
+	Output string
+	// Tags are the build tags used to load the packages.
+	Tags []string
+}
+
+func (cfg Config) source() string {
+	if cfg.Source != "" {
+		return cfg.Source
+	}
+	return "initgen.go"
+}
+
+func (cfg Config) output() string {
+	if cfg.Output != "" {
+		return cfg.Output
+	}
+	return "init.go"
+}
+
+// target is a package with di.Generate calls.
+type target struct {
+	pkg *packages.Package
+	// containers of the injectors in the order of the calls.
+	containers []*container
+	// output is the path of the generated file.
+	output string
+}
+
+// targets type checks the packages matching the patterns and resolves the
+// containers of the di.Generate and di.GenerateNamed calls in the source file
+// of each package. Packages without the source file or a call in it are skipped.
+func (cfg Config) targets(patterns []string) ([]target, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"."}
+	}
+
+	pkgs, err := cfg.load(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []target
+	for _, pkg := range pkgs {
+		l := &loader{pkg: pkg, info: pkg.TypesInfo}
+
+		f, path, ok := l.file(cfg.source())
+		if !ok {
+			continue
+		}
+
+		if err := sourceErrors(pkg, path); err != nil {
+			return nil, err
+		}
+
+		calls := l.generateCalls(f)
+		if len(calls) == 0 {
+			continue
+		}
+
+		var containers []*container
+		names := make(map[string]bool)
+		for _, call := range calls {
+			c, err := l.container(call)
+			if err != nil {
+				return nil, err
+			}
+			if names[c.name] {
+				if c.name == "" {
+					return nil, l.errorf(call, "di.Generate is called more than once, use di.GenerateNamed")
+				}
+				return nil, l.errorf(call, "injector '%s' is already generated", c.name)
+			}
+			names[c.name] = true
+
+			if err := c.resolve(); err != nil {
+				return nil, fmt.Errorf("initgen: %s: %w", pkg.PkgPath, err)
+			}
+			containers = append(containers, c)
+		}
+
+		output := cfg.output()
+		if !filepath.IsAbs(output) {
+			output = filepath.Join(filepath.Dir(path), output)
+		}
+
+		targets = append(targets, target{pkg: pkg, containers: containers, output: output})
+	}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("initgen: no %s with a di.Generate call found in %s", cfg.source(), strings.Join(patterns, " "))
+	}
+
+	// The injectors of several packages would overwrite each other.
+	if filepath.IsAbs(cfg.output()) && len(targets) > 1 {
+		return nil, fmt.Errorf("initgen: absolute output path %s matches %d packages in %s", cfg.output(), len(targets), strings.Join(patterns, " "))
+	}
+
+	return targets, nil
+}
+
+// File is a generated file.
+type File struct {
+	Path string
+	Src  []byte
+}
+
+// Generate generates the injectors of each package matching the patterns
+// into a file per package. An injector builds each singleton once, in dependency order.
+func (cfg Config) Generate(patterns ...string) ([]File, error) {
+	targets, err := cfg.targets(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]File, 0, len(targets))
+	for _, t := range targets {
+		src, err := t.generate()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{Path: t.output, Src: src})
+	}
+
+	return files, nil
+}
+
+func (t target) generate() ([]byte, error) {
+	var stmts []generator.Statement
+	used := make(map[string]bool)
+	declared := make(map[string]bool)
+
+	for _, c := range t.containers {
+		g := newCodegen(c, t.pkg.Types, declared)
+
+		stmts = append(stmts, g.injector()...)
+		if c.initializers {
+			stmts = append(stmts, g.initializers()...)
+		}
+
+		if g.err != nil {
+			return nil, g.err
+		}
+
+		for _, imp := range g.imports() {
+			used[imp] = true
+		}
+	}
+
+	imports := make([]string, 0, len(used))
+	for imp := range used {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+
+	root := generator.NewRoot(
+		generator.NewComment(strings.TrimPrefix(header, "//")),
+		generator.NewPackage(t.pkg.Name),
+		generator.NewNewline(),
+		generator.NewImport(imports...),
+		generator.NewNewline(),
+	).AddStatements(stmts...)
+
+	generated, err := root.Generate(0)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source([]byte(generated))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := t.check(src); err != nil {
+		return nil, err
+	}
+
+	return src, nil
+}
+
+// check type checks the generated source with the other files of the package.
+func (t target) check(src []byte) error {
+	f, err := parser.ParseFile(t.pkg.Fset, t.output, src, 0)
+	if err != nil {
+		return err
+	}
+
+	files := []*ast.File{f}
+	for i, path := range t.pkg.CompiledGoFiles {
+		if path != t.output {
+			files = append(files, t.pkg.Syntax[i])
+		}
+	}
+
+	// The generated code may import any package the loaded package depends on.
+	deps := make(map[string]*types.Package)
+	packages.Visit([]*packages.Package{t.pkg}, nil, func(pkg *packages.Package) {
+		deps[pkg.PkgPath] = pkg.Types
+	})
+
+	var errs []error
+	conf := types.Config{
+		Importer: importerFunc(func(path string) (*types.Package, error) {
+			if pkg, ok := deps[path]; ok {
+				return pkg, nil
+			}
+			return nil, fmt.Errorf("package %s not found", path)
+		}),
+		Sizes: t.pkg.TypesSizes,
+		Error: func(err error) {
+			// Only the errors of the generated code are reported.
+			if typeErr, ok := err.(types.Error); ok && typeErr.Fset.File(typeErr.Pos).Name() == t.output {
+				errs = append(errs, err)
+			}
+		},
+	}
+	conf.Check(t.pkg.PkgPath, t.pkg.Fset, files, nil)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("initgen: the generated code does not compile: %w", errors.Join(errs...))
+	}
+
+	return nil
+}
+
+type importerFunc func(path string) (*types.Package, error)
+
+func (f importerFunc) Import(path string) (*types.Package, error) {
+	return f(path)
+}
+
+// WriteGraph writes the dependency graphs of the injectors of the packages
+// matching the patterns to w in format as a single document.
+func (cfg Config) WriteGraph(w io.Writer, format render.Format, patterns ...string) error {
+	targets, err := cfg.targets(patterns)
+	if err != nil {
+		return err
+	}
+
+	var injectors []render.Injector
+	for _, t := range targets {
+		for _, c := range t.containers {
+			injectors = append(injectors, render.Injector{
+				Package: t.pkg.PkgPath,
+				Name:    c.name + "Injector",
+				Graph:   c.graph(),
+			})
+		}
+	}
+
+	return render.WriteInjectors(w, injectors, format)
+}
+
+// graph creates the graph of the items in dependency order.
+func (c *container) graph() render.Graph {
+	return render.Build(c.order, func(it *item) render.Node {
+		return render.Node{
+			Type:     typeString(it.typ),
+			Name:     it.name,
+			Provider: providerName(it),
+			Lifetime: it.lifetime.String(),
+			Group:    it.group,
+		}
+	}, func(it *item) []render.Dep[*item] {
+		var deps []render.Dep[*item]
+		for _, p := range it.params {
+			deps = append(deps, c.paramDeps(p)...)
+		}
+		return deps
+	})
+}
+
+// paramDeps returns the items a provider parameter depends on
+// for the graph. Keep in sync with di.Container.paramDeps.
+func (c *container) paramDeps(p param) []render.Dep[*item] {
+	switch {
+	case p.ctx:
+		return nil
+
+	case p.in:
+		var deps []render.Dep[*item]
+		for _, f := range p.fields {
+			deps = append(deps, c.paramDeps(f)...)
+		}
+		return deps
+
+	case p.group != "":
+		members := c.groups[p.group]
+		deps := make([]render.Dep[*item], 0, len(members))
+		for _, member := range members {
+			deps = append(deps, render.Dep[*item]{Item: member})
+		}
+		return deps
+
+	default:
+		it, factory, ok := c.lookupParam(p)
+		if !ok {
+			return nil
+		}
+		return []render.Dep[*item]{{Item: it, Optional: p.optional, Factory: factory}}
+	}
+}
+
+// typeString of typ qualified by package names.
+func typeString(typ types.Type) string {
+	return types.TypeString(typ, func(p *types.Package) string {
+		return p.Name()
+	})
+}
+
+// providerName returns the package qualified name of the provider of an item.
+// Keep in sync with di.providerName.
+func providerName(it *item) string {
+	if it.field != "" {
+		return typeString(it.params[0].typ) + "." + it.field
+	}
+	return it.provider.Pkg().Name() + "." + it.provider.Name()
+}
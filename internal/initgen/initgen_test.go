@@ -0,0 +1,193 @@
+package initgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/ambiguous"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/capture"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/cycle"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/duplicate"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/duplicatenamed"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/emptyname"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/group"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/hook"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/implement"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/literal"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/missing"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/option"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/outgroup"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/registered"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/returns"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/errors/transientcleanup"
+	"github.com/mgnsk/di-container/internal/render"
+)
+
+const exampleDir = "../../example"
+
+func TestGenerate(t *testing.T) {
+	files, err := Config{Dir: exampleDir}.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(files) != 1 || filepath.Base(files[0].Path) != "init.go" {
+		t.Fatalf("expected init.go, got %v", files)
+	}
+
+	expected, err := os.ReadFile(filepath.Join(exampleDir, "init.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(files[0].Src) != string(expected) {
+		t.Fatalf("expected:\n%s\ngot:\n%s", expected, files[0].Src)
+	}
+}
+
+func TestGeneratePatterns(t *testing.T) {
+	files, err := Config{Dir: exampleDir, Output: "injector.go"}.Generate("./...")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Packages without initgen.go are skipped.
+	if len(files) != 1 || files[0].Path != filepath.Join(absDir(t, exampleDir), "injector.go") {
+		t.Fatalf("expected example/injector.go, got %v", files)
+	}
+
+	if _, err := (Config{Dir: exampleDir, Source: "missing.go"}).Generate("./..."); err == nil {
+		t.Fatal("expected an error when no package has the source file")
+	}
+
+	output := filepath.Join(t.TempDir(), "init.go")
+	if _, err := (Config{Dir: "testdata", Output: output}).Generate("./injector", "./features"); err == nil {
+		t.Fatal("expected an error for an absolute output path with several packages")
+	}
+}
+
+func absDir(t *testing.T, dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return abs
+}
+
+func TestWriteGraph(t *testing.T) {
+	var b strings.Builder
+	if err := (Config{Dir: exampleDir}).WriteGraph(&b, render.DOT); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Count(b.String(), "digraph") != 1 {
+		t.Fatalf("expected a single graph:\n%s", b.String())
+	}
+
+	for _, line := range []string{
+		"\tsubgraph cluster_0 {\n\t\tlabel=\"github.com/mgnsk/di-container/example.Injector\";",
+		`i0_n2 [label="example.mySentence\nexample.newMySentence"];`,
+		`i0_n5 [label="*example.MyService\nexample.newMyServiceProvider"];`,
+		"i0_n5 -> i0_n3;",
+		"\tsubgraph cluster_1 {\n\t\tlabel=\"github.com/mgnsk/di-container/example.SentenceInjector\";",
+		"i1_n2 -> i1_n0;",
+	} {
+		if !strings.Contains(b.String(), line) {
+			t.Fatalf("expected %q in:\n%s", line, b.String())
+		}
+	}
+
+	b.Reset()
+	if err := (Config{Dir: exampleDir}).WriteGraph(&b, render.Mermaid); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(b.String(), "flowchart TD") != 1 ||
+		!strings.Contains(b.String(), `subgraph i1["github.com/mgnsk/di-container/example.SentenceInjector"]`) {
+		t.Fatalf("unexpected mermaid output:\n%s", b.String())
+	}
+
+	b.Reset()
+	if err := (Config{Dir: exampleDir}).WriteGraph(&b, render.JSON); err != nil {
+		t.Fatal(err)
+	}
+
+	var injectors []render.Injector
+	if err := json.Unmarshal([]byte(b.String()), &injectors); err != nil {
+		t.Fatal(err)
+	}
+	if len(injectors) != 2 ||
+		injectors[0].Name != "Injector" || len(injectors[0].Nodes) != 6 ||
+		injectors[1].Name != "SentenceInjector" || len(injectors[1].Nodes) != 3 ||
+		injectors[1].Package != "github.com/mgnsk/di-container/example" {
+		t.Fatalf("unexpected json output:\n%s", b.String())
+	}
+}
+
+// TestErrors checks that the static checks of each package in testdata/errors
+// report the same error as calling its Generate function.
+func TestErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		generate func()
+		err      string
+		// static reports whether only initgen reports the error.
+		static bool
+	}{
+		{"ambiguous", ambiguous.Generate, "Ambiguous binding for type 'ambiguous.G': bound to both 'ambiguous.A' and 'ambiguous.B'", false},
+		{"implement", implement.Generate, "Type 'implement.A' does not implement 'implement.G'", false},
+		{"emptyname", emptyname.Generate, "name must not be empty", false},
+		{"registered", registered.Generate, "item type 'registered.A' is already registered", false},
+		{"missing", missing.Generate, "Missing provider for type 'missing.B' required by 'missing.A'", false},
+		{"cycle", cycle.Generate, "Dependency cycle detected: cycle.A -> cycle.B -> cycle.A", false},
+		{"outgroup", outgroup.Generate, "result struct 'outgroup.Results' cannot be grouped", false},
+		{"capture", capture.Generate, "Singleton 'capture.A' depends on transient 'capture.B', inject di.Provider[capture.B] instead", false},
+		{"hook", hook.Generate, "hook for type '*hook.B' cannot be used with provided type 'hook.A'", false},
+		{"transientcleanup", transientcleanup.Generate, "transient item type 'transientcleanup.A' cannot have a cleanup function or lifecycle hooks", false},
+		{"returns", returns.Generate, "the type 'returns.B' of the second return value of provider must be an error or func()", false},
+		{"group", group.Generate, "group parameter of type 'group.A' must be a slice", false},
+		{"literal", literal.Generate, "Register functions must not be literal", true},
+		{"option", option.Generate, "unsupported option", true},
+		{"duplicate", duplicate.Generate, "di.Generate is called more than once, use di.GenerateNamed", true},
+		{"duplicatenamed", duplicatenamed.Generate, "injector 'Server' is already generated", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Config{Dir: filepath.Join("testdata", "errors", tc.name)}.Generate()
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Fatalf("expected initgen error '%s', got '%v'", tc.err, err)
+			}
+
+			runtimeErr := generateError(tc.generate)
+			if tc.static {
+				if runtimeErr != "" {
+					t.Fatalf("expected no runtime error, got '%s'", runtimeErr)
+				}
+			} else if !strings.Contains(runtimeErr, tc.err) {
+				t.Fatalf("expected runtime error '%s', got '%s'", tc.err, runtimeErr)
+			}
+		})
+	}
+}
+
+func TestSourceTypeError(t *testing.T) {
+	_, err := Config{Dir: filepath.Join("testdata", "errors", "typeerror")}.Generate()
+	if err == nil || !strings.Contains(err.Error(), "initgen.go:7:14: undefined: NewService") {
+		t.Fatalf("expected the type error of initgen.go, got '%v'", err)
+	}
+}
+
+// generateError returns the panic of generate.
+func generateError(generate func()) (err string) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Sprint(r)
+		}
+	}()
+	generate()
+	return ""
+}
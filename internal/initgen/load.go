@@ -0,0 +1,542 @@
+// Package initgen generates injectors from the di.Generate registrations
+// of a package by type checking its source, without running any of its code.
+package initgen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// diImport is the import path of the di package.
+const diImport = "github.com/mgnsk/di-container/di"
+
+// header marks generated files.
+const header = "// DO NOT EDIT. This code is generated by initgen."
+
+// load type checks the packages matching the patterns. The declarations of
+// generated files are dropped so that a stale injector does not fail the type check.
+// Dependencies are type checked from source, which does not depend on the
+// export data format of the installed Go version. Only their declarations
+// are needed, so the bodies of their functions are dropped.
+func (cfg Config) load(patterns []string) ([]*packages.Package, error) {
+	var buildFlags []string
+	if len(cfg.Tags) > 0 {
+		buildFlags = []string{"-tags=" + strings.Join(cfg.Tags, ",")}
+	}
+
+	roots, err := packages.Load(&packages.Config{
+		Mode:       packages.NeedFiles | packages.NeedCompiledGoFiles,
+		Dir:        cfg.Dir,
+		BuildFlags: buildFlags,
+	}, patterns...)
+	if err != nil {
+		return nil, err
+	}
+
+	rootFiles := make(map[string]bool)
+	for _, pkg := range roots {
+		for _, path := range pkg.CompiledGoFiles {
+			rootFiles[path] = true
+		}
+	}
+
+	return packages.Load(&packages.Config{
+		Mode: packages.NeedName |
+			packages.NeedFiles |
+			packages.NeedCompiledGoFiles |
+			packages.NeedSyntax |
+			packages.NeedTypes |
+			packages.NeedTypesInfo |
+			packages.NeedTypesSizes |
+			packages.NeedImports |
+			packages.NeedDeps,
+		Dir:        cfg.Dir,
+		BuildFlags: buildFlags,
+		ParseFile: func(fset *token.FileSet, filename string, src []byte) (*ast.File, error) {
+			if !rootFiles[filename] {
+				f, err := parser.ParseFile(fset, filename, src, parser.SkipObjectResolution)
+				if err == nil {
+					for _, decl := range f.Decls {
+						if fn, ok := decl.(*ast.FuncDecl); ok {
+							fn.Body = nil
+						}
+					}
+				}
+				return f, err
+			}
+
+			f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+			if err == nil && bytes.HasPrefix(src, []byte(header)) {
+				f.Decls = nil
+				f.Imports = nil
+			}
+			return f, err
+		},
+	}, patterns...)
+}
+
+// sourceErrors joins the errors of a package in the source file at path
+// and the errors without a position. Errors in other files are ignored:
+// they may refer to the declarations of the generated file, which are dropped.
+func sourceErrors(pkg *packages.Package, path string) error {
+	var errs []error
+	for _, err := range pkg.Errors {
+		if err.Pos == "" || err.Pos == "-" || strings.HasPrefix(err.Pos, path+":") {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parser of the registrations in a type checked package.
+type loader struct {
+	pkg  *packages.Package
+	info *types.Info
+}
+
+// file returns the syntax and the path of the file with the name.
+func (l *loader) file(name string) (*ast.File, string, bool) {
+	for i, path := range l.pkg.CompiledGoFiles {
+		if filepath.Base(path) == name {
+			return l.pkg.Syntax[i], path, true
+		}
+	}
+	return nil, "", false
+}
+
+// diObject returns the name of the object of the di package expr refers to.
+func (l *loader) diObject(expr ast.Expr) (string, bool) {
+	switch e := expr.(type) {
+	case *ast.IndexExpr:
+		return l.diObject(e.X)
+	case *ast.IndexListExpr:
+		return l.diObject(e.X)
+	case *ast.SelectorExpr:
+		return l.diObject(e.Sel)
+	case *ast.Ident:
+		obj := l.info.Uses[e]
+		if obj == nil || obj.Pkg() == nil || obj.Pkg().Path() != diImport {
+			return "", false
+		}
+		return obj.Name(), true
+	default:
+		return "", false
+	}
+}
+
+// generateCalls returns the di.Generate and di.GenerateNamed calls of the file.
+func (l *loader) generateCalls(f *ast.File) []*ast.CallExpr {
+	var calls []*ast.CallExpr
+	ast.Inspect(f, func(n ast.Node) bool {
+		if call, ok := n.(*ast.CallExpr); ok {
+			if name, ok := l.diObject(call.Fun); ok && (name == "Generate" || name == "GenerateNamed") {
+				calls = append(calls, call)
+				return false
+			}
+		}
+		return true
+	})
+	return calls
+}
+
+// container parses the registrations of a di.Generate or di.GenerateNamed call.
+func (l *loader) container(call *ast.CallExpr) (*container, error) {
+	c := newContainer()
+
+	args := call.Args
+	if fn, _ := l.diObject(call.Fun); fn == "GenerateNamed" {
+		name, err := l.stringValue(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if !token.IsIdentifier(name) {
+			return nil, l.errorf(args[0], "injector name '%s' must be an identifier", name)
+		}
+		c.name = name
+		args = args[1:]
+	}
+
+	register, ok := args[0].(*ast.FuncLit)
+	if !ok || len(register.Type.Params.List) != 1 || len(register.Type.Params.List[0].Names) != 1 {
+		return nil, l.errorf(call, "di.Generate must be called with a function literal")
+	}
+	recv := l.info.Defs[register.Type.Params.List[0].Names[0]]
+
+	for _, arg := range args[1:] {
+		switch name, _ := l.diObject(arg); name {
+		case "Initializers":
+			c.initializers = true
+		default:
+			return nil, l.errorf(arg, "unsupported generate option")
+		}
+	}
+
+	var err error
+	ast.Inspect(register.Body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || err != nil {
+			return err == nil
+		}
+		sel, ok := call.Fun.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); !ok || l.info.Uses[x] != recv {
+			return true
+		}
+
+		switch sel.Sel.Name {
+		case "Register":
+			err = l.register(c, call, "", call.Args[0], call.Args[1:])
+		case "RegisterNamed":
+			var name string
+			if name, err = l.stringValue(call.Args[0]); err == nil {
+				if name == "" {
+					err = l.errorf(call.Args[0], "name must not be empty")
+				} else {
+					err = l.register(c, call, name, call.Args[1], call.Args[2:])
+				}
+			}
+		case "Bind":
+			err = l.bind(c, call)
+		case "RegisterValue":
+			err = l.errorf(call, "RegisterValue is not supported")
+		default:
+			err = l.errorf(call, "unsupported container method '%s'", sel.Sel.Name)
+		}
+		return false
+	})
+
+	return c, err
+}
+
+func (l *loader) errorf(node ast.Node, format string, args ...interface{}) error {
+	pos := l.pkg.Fset.Position(node.Pos())
+	return fmt.Errorf("initgen: %s: %s", pos, fmt.Sprintf(format, args...))
+}
+
+// stringValue returns the value of a constant string expression.
+func (l *loader) stringValue(expr ast.Expr) (string, error) {
+	tv, ok := l.info.Types[expr]
+	if !ok || tv.Value == nil || tv.Value.Kind() != constant.String {
+		return "", l.errorf(expr, "expected a constant string")
+	}
+	return constant.StringVal(tv.Value), nil
+}
+
+// options parses the registration options. Keep in sync with di.Option.
+func (l *loader) options(args []ast.Expr) (options, error) {
+	var o options
+
+	for _, arg := range args {
+		call, isCall := arg.(*ast.CallExpr)
+		name, ok := l.diObject(arg)
+		if isCall {
+			name, ok = l.diObject(call.Fun)
+		}
+		if !ok {
+			return o, l.errorf(arg, "unsupported option")
+		}
+
+		var err error
+		switch name {
+		case "Singleton":
+			o.transient = false
+		case "Transient":
+			o.transient = true
+		case "Group":
+			o.group, err = l.stringValue(call.Args[0])
+		case "ParamTags":
+			for _, tag := range call.Args {
+				var s string
+				if s, err = l.stringValue(tag); err != nil {
+					break
+				}
+				o.paramTags = append(o.paramTags, s)
+			}
+		case "OnStart", "OnClose":
+			// Lifecycle hooks only apply to containers, only their type is checked.
+			hook := l.info.TypeOf(call.Fun).(*types.Signature).Params().At(0).Type().Underlying().(*types.Signature)
+			o.hooks = append(o.hooks, hook.Params().At(1).Type())
+		default:
+			err = l.errorf(arg, "unsupported option 'di.%s'", name)
+		}
+		if err != nil {
+			return o, err
+		}
+	}
+
+	return o, nil
+}
+
+// register parses a provider registration.
+// Keep in sync with di.Container.register.
+func (l *loader) register(c *container, call *ast.CallExpr, name string, provider ast.Expr, opts []ast.Expr) error {
+	fn, err := l.provider(provider)
+	if err != nil {
+		return err
+	}
+
+	o, err := l.options(opts)
+	if err != nil {
+		return err
+	}
+	if name != "" && o.group != "" {
+		return l.errorf(call, "provider cannot be both named and grouped")
+	}
+
+	sig := fn.Type().(*types.Signature)
+	results := sig.Results()
+	if results.Len() == 0 || results.Len() > 3 {
+		return l.errorf(call, "provider must return at least 1 value and not more than 3")
+	}
+
+	it := &item{
+		typ:      results.At(0).Type(),
+		name:     name,
+		group:    o.group,
+		provider: fn,
+		index:    len(c.all),
+	}
+	if o.transient {
+		it.lifetime = transient
+	}
+
+	// The value may be followed by a cleanup function and an error, in this order.
+	switch results.Len() {
+	case 2:
+		it.returnsCleanup = isCleanup(results.At(1).Type())
+		it.returnsErr = isError(results.At(1).Type())
+		if !it.returnsCleanup && !it.returnsErr {
+			return l.errorf(call, "the type '%s' of the second return value of provider must be an error or func()", typeString(results.At(1).Type()))
+		}
+	case 3:
+		it.returnsCleanup = true
+		it.returnsErr = true
+		if !isCleanup(results.At(1).Type()) {
+			return l.errorf(call, "the type '%s' of the second return value of provider must be func()", typeString(results.At(1).Type()))
+		}
+		if !isError(results.At(2).Type()) {
+			return l.errorf(call, "the type '%s' of the third return value of provider must be an error", typeString(results.At(2).Type()))
+		}
+	}
+
+	if it.group != "" && embeds(it.typ, "Out") {
+		return l.errorf(call, "result struct '%s' cannot be grouped", typeString(it.typ))
+	}
+
+	for _, hook := range o.hooks {
+		if !types.AssignableTo(it.typ, hook) {
+			return l.errorf(call, "hook for type '%s' cannot be used with provided type '%s'", typeString(hook), typeString(it.typ))
+		}
+	}
+
+	if it.lifetime == transient && (it.returnsCleanup || len(o.hooks) > 0) {
+		return l.errorf(call, "transient item type '%s' cannot have a cleanup function or lifecycle hooks", it)
+	}
+
+	if it.params, err = params(sig, o.paramTags); err != nil {
+		return l.errorf(call, "%s", err)
+	}
+
+	if err := c.add(it); err != nil {
+		return l.errorf(call, "%s", err)
+	}
+
+	if embeds(it.typ, "Out") {
+		if err := c.addOut(it); err != nil {
+			return l.errorf(call, "%s", err)
+		}
+	}
+
+	return nil
+}
+
+// provider returns the package level function a provider expression refers to.
+func (l *loader) provider(expr ast.Expr) (*types.Func, error) {
+	var ident *ast.Ident
+	switch e := expr.(type) {
+	case *ast.FuncLit:
+		return nil, l.errorf(expr, "Register functions must not be literal")
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		ident = e.Sel
+	}
+
+	if ident != nil {
+		if fn, ok := l.info.Uses[ident].(*types.Func); ok && fn.Type().(*types.Signature).Recv() == nil {
+			return fn, nil
+		}
+	}
+
+	return nil, l.errorf(expr, "invalid Register argument '%s': provider must be a package level function", types.ExprString(expr))
+}
+
+// bind parses an interface binding. Keep in sync with di.Container.Bind.
+func (l *loader) bind(c *container, call *ast.CallExpr) error {
+	var typs [2]types.Type
+	for i, arg := range call.Args {
+		ptr, ok := l.info.TypeOf(arg).(*types.Pointer)
+		if !ok {
+			return l.errorf(arg, "type '%s' must be passed as a pointer", typeString(l.info.TypeOf(arg)))
+		}
+		typs[i] = ptr.Elem()
+	}
+
+	if !types.IsInterface(typs[0]) {
+		return l.errorf(call, "bound type '%s' must be an interface", typeString(typs[0]))
+	}
+
+	c.bindings = append(c.bindings, binding{iface: typs[0], concrete: typs[1]})
+
+	return nil
+}
+
+// params parses the parameters of a provider signature.
+// Keep in sync with di.options.params.
+func params(sig *types.Signature, paramTags []string) ([]param, error) {
+	if len(paramTags) > sig.Params().Len() {
+		return nil, fmt.Errorf("provider has %d parameters but %d param tags", sig.Params().Len(), len(paramTags))
+	}
+
+	ps := make([]param, sig.Params().Len())
+	for i := range ps {
+		typ := sig.Params().At(i).Type()
+
+		var err error
+		switch {
+		case embeds(typ, "In"):
+			if i < len(paramTags) && paramTags[i] != "" {
+				return nil, fmt.Errorf("parameter struct '%s' cannot be tagged", typeString(typ))
+			}
+			ps[i], err = newInParam(typ)
+		case i < len(paramTags):
+			ps[i], err = newParam(typ, reflect.StructTag(paramTags[i]))
+		default:
+			ps[i], err = newParam(typ, "")
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ps, nil
+}
+
+// newParam parses a parameter tagged with tag. Keep in sync with di.newParam.
+func newParam(typ types.Type, tag reflect.StructTag) (param, error) {
+	p := param{
+		typ:      typ,
+		name:     tag.Get("name"),
+		group:    tag.Get("group"),
+		optional: tag.Get("optional") == "true",
+	}
+
+	// The context parameter receives the build context.
+	p.ctx = isNamed(typ, "context", "Context") && p.name == "" && p.group == ""
+
+	if named, ok := typ.(*types.Named); ok && isNamed(typ, diImport, "Optional") {
+		p.typ = named.TypeArgs().At(0)
+		p.optional = true
+		p.wrapped = true
+	}
+
+	if p.group != "" {
+		if p.name != "" {
+			return p, fmt.Errorf("parameter of type '%s' cannot be both named and grouped", typeString(typ))
+		}
+		if _, ok := typ.Underlying().(*types.Slice); !ok {
+			return p, fmt.Errorf("group parameter of type '%s' must be a slice", typeString(typ))
+		}
+	}
+
+	return p, nil
+}
+
+// newInParam parses the fields of a parameter struct embedding In.
+// Keep in sync with di.newInParam.
+func newInParam(typ types.Type) (param, error) {
+	p := param{typ: typ, in: true}
+
+	st := typ.Underlying().(*types.Struct)
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		if f.Embedded() && isNamed(f.Type(), diImport, "In") {
+			continue
+		}
+		if !f.Exported() {
+			return p, fmt.Errorf("field '%s' of parameter struct '%s' must be exported", f.Name(), typeString(typ))
+		}
+
+		fp, err := newParam(f.Type(), reflect.StructTag(st.Tag(i)))
+		if err != nil {
+			return p, err
+		}
+		fp.field = f.Name()
+		p.fields = append(p.fields, fp)
+	}
+
+	return p, nil
+}
+
+// isNamed reports whether typ is the named type pkg.name.
+func isNamed(typ types.Type, pkg, name string) bool {
+	named, ok := typ.(*types.Named)
+	if !ok {
+		return false
+	}
+	obj := named.Obj()
+	return obj.Pkg() != nil && obj.Pkg().Path() == pkg && obj.Name() == name
+}
+
+// embeds reports whether typ is a struct embedding the di marker type.
+func embeds(typ types.Type, marker string) bool {
+	st, ok := typ.Underlying().(*types.Struct)
+	if !ok {
+		return false
+	}
+	for i := 0; i < st.NumFields(); i++ {
+		if f := st.Field(i); f.Embedded() && isNamed(f.Type(), diImport, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+var errorType = types.Universe.Lookup("error").Type()
+
+func isError(typ types.Type) bool {
+	return types.Implements(typ, errorType.Underlying().(*types.Interface))
+}
+
+// isCleanup reports whether typ is func().
+func isCleanup(typ types.Type) bool {
+	sig, ok := typ.(*types.Signature)
+	return ok && sig.Params().Len() == 0 && sig.Results().Len() == 0 && !sig.Variadic()
+}
+
+// factoryTarget returns T of a factory type func() (T, error).
+// Keep in sync with di.factoryTarget.
+func factoryTarget(typ types.Type) (types.Type, bool) {
+	sig, ok := typ.Underlying().(*types.Signature)
+	if !ok ||
+		sig.Variadic() ||
+		sig.Params().Len() != 0 ||
+		sig.Results().Len() != 2 ||
+		!types.Identical(sig.Results().At(1).Type(), errorType) {
+		return nil, false
+	}
+	return sig.Results().At(0).Type(), true
+}
@@ -0,0 +1,26 @@
+package ambiguous
+
+import "github.com/mgnsk/di-container/di"
+
+type G interface{ G() }
+
+type A struct{}
+
+func (A) G() {}
+
+func NewA() A { return A{} }
+
+type B struct{}
+
+func (B) G() {}
+
+func NewB() B { return B{} }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA)
+		c.Register(NewB)
+		c.Bind((*G)(nil), (*A)(nil))
+		c.Bind((*G)(nil), (*B)(nil))
+	})
+}
@@ -0,0 +1,18 @@
+package cycle
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+type B struct{}
+
+func NewA(B) A { return A{} }
+
+func NewB(A) B { return B{} }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA)
+		c.Register(NewB)
+	})
+}
@@ -0,0 +1,16 @@
+package duplicate
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+func NewA() A { return A{} }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA)
+	})
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA)
+	})
+}
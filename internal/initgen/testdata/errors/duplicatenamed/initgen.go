@@ -0,0 +1,16 @@
+package duplicatenamed
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+func NewA() A { return A{} }
+
+func Generate() {
+	di.GenerateNamed("Server", func(c *di.Container) {
+		c.Register(NewA)
+	})
+	di.GenerateNamed("Server", func(c *di.Container) {
+		c.Register(NewA)
+	})
+}
@@ -0,0 +1,13 @@
+package emptyname
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+func NewA() A { return A{} }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.RegisterNamed("", NewA)
+	})
+}
@@ -0,0 +1,13 @@
+package group
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+func NewA(A) int { return 0 }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA, di.ParamTags(`group:"as"`))
+	})
+}
@@ -0,0 +1,21 @@
+package hook
+
+import (
+	"context"
+
+	"github.com/mgnsk/di-container/di"
+)
+
+type A struct{}
+
+type B struct{}
+
+func NewA() A { return A{} }
+
+func StartB(context.Context, *B) error { return nil }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA, di.OnStart(StartB))
+	})
+}
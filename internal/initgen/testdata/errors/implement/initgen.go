@@ -0,0 +1,16 @@
+package implement
+
+import "github.com/mgnsk/di-container/di"
+
+type G interface{ G() }
+
+type A struct{}
+
+func NewA() A { return A{} }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA)
+		c.Bind((*G)(nil), (*A)(nil))
+	})
+}
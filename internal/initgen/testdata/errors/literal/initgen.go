@@ -0,0 +1,11 @@
+package literal
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(func() A { return A{} })
+	})
+}
@@ -0,0 +1,15 @@
+package missing
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+type B struct{}
+
+func NewA(B) A { return A{} }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA)
+	})
+}
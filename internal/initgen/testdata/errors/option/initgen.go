@@ -0,0 +1,14 @@
+package option
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+func NewA() A { return A{} }
+
+func Generate() {
+	transient := di.Transient
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA, transient)
+	})
+}
@@ -0,0 +1,17 @@
+package outgroup
+
+import "github.com/mgnsk/di-container/di"
+
+type Results struct {
+	di.Out
+
+	N int
+}
+
+func NewResults() Results { return Results{} }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewResults, di.Group("results"))
+	})
+}
@@ -0,0 +1,16 @@
+package registered
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+func NewA() A { return A{} }
+
+func OtherA() A { return A{} }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA)
+		c.Register(OtherA)
+	})
+}
@@ -0,0 +1,15 @@
+package returns
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+type B struct{}
+
+func NewA() (A, B) { return A{}, B{} }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA)
+	})
+}
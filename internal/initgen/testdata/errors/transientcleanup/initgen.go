@@ -0,0 +1,13 @@
+package transientcleanup
+
+import "github.com/mgnsk/di-container/di"
+
+type A struct{}
+
+func NewA() (A, func()) { return A{}, func() {} }
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewA, di.Transient)
+	})
+}
@@ -0,0 +1,9 @@
+package typeerror
+
+import "github.com/mgnsk/di-container/di"
+
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewService)
+	})
+}
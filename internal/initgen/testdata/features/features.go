@@ -0,0 +1,70 @@
+// Package features uses groups, parameter and result structs,
+// optional dependencies, parameter tags and named items.
+package features
+
+import "github.com/mgnsk/di-container/di"
+
+type Handler interface {
+	Path() string
+}
+
+type handler string
+
+func (h handler) Path() string {
+	return string(h)
+}
+
+type DB struct {
+	Name string
+}
+
+// Results provides the databases and a handler.
+type Results struct {
+	di.Out
+
+	Primary *DB     `name:"primary"`
+	Replica *DB     `name:"replica"`
+	Users   Handler `group:"handlers"`
+}
+
+func NewResults() Results {
+	return Results{
+		Primary: &DB{Name: "primary"},
+		Replica: &DB{Name: "replica"},
+		Users:   handler("/users"),
+	}
+}
+
+func NewHealth() Handler {
+	return handler("/health")
+}
+
+type Logger struct{}
+
+type Cache struct{}
+
+type Params struct {
+	di.In
+
+	Primary  *DB       `name:"primary"`
+	Logger   *Logger   `optional:"true"`
+	Handlers []Handler `group:"handlers"`
+}
+
+type Repo struct {
+	Params  Params
+	Replica *DB
+	Cache   di.Optional[*Cache]
+	Logger  *Logger
+	Port    Port
+}
+
+type Port int
+
+func NewPort() Port {
+	return 8080
+}
+
+func NewRepo(p Params, replica *DB, cache di.Optional[*Cache], logger *Logger, port di.Optional[Port]) *Repo {
+	return &Repo{Params: p, Replica: replica, Cache: cache, Logger: logger, Port: port.Value}
+}
@@ -0,0 +1,39 @@
+// DO NOT EDIT. This code is generated by initgen.
+package features
+
+import (
+	"github.com/mgnsk/di-container/di"
+)
+
+// Injector holds the singletons built by NewInjector.
+type Injector struct {
+	Results          Results
+	DBPrimary        *DB
+	DBReplica        *DB
+	HandlerHandlers0 Handler
+	HandlerHandlers1 Handler
+	PortHttp         Port
+	Repo             *Repo
+}
+
+// NewInjector builds each singleton once, in dependency order.
+func NewInjector() *Injector {
+	results := NewResults()
+	dbPrimary := results.Primary
+	dbReplica := results.Replica
+	handlerHandlers0 := results.Users
+	handlerHandlers1 := NewHealth()
+	portHttp := NewPort()
+	handlers := []Handler{handlerHandlers0, handlerHandlers1}
+	params := Params{Primary: dbPrimary, Handlers: handlers}
+	repo := NewRepo(params, dbReplica, di.Optional[*Cache]{}, nil, di.Optional[Port]{Value: portHttp, OK: true})
+	return &Injector{
+		Results:          results,
+		DBPrimary:        dbPrimary,
+		DBReplica:        dbReplica,
+		HandlerHandlers0: handlerHandlers0,
+		HandlerHandlers1: handlerHandlers1,
+		PortHttp:         portHttp,
+		Repo:             repo,
+	}
+}
@@ -0,0 +1,13 @@
+package features
+
+import "github.com/mgnsk/di-container/di"
+
+// Generate registers the container for code generation.
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewResults)
+		c.Register(NewHealth, di.Group("handlers"))
+		c.RegisterNamed("http", NewPort)
+		c.Register(NewRepo, di.ParamTags("", `name:"replica"`, "", `optional:"true"`, `name:"http"`))
+	})
+}
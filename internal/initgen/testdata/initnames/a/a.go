@@ -0,0 +1,7 @@
+package a
+
+type Config struct{}
+
+func NewConfig() Config {
+	return Config{}
+}
@@ -0,0 +1,7 @@
+package b
+
+type Config struct{}
+
+func NewConfig() *Config {
+	return &Config{}
+}
@@ -0,0 +1,49 @@
+// DO NOT EDIT. This code is generated by initgen.
+package initnames
+
+import (
+	"github.com/mgnsk/di-container/internal/initgen/testdata/initnames/a"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/initnames/b"
+)
+
+// Injector holds the singletons built by NewInjector.
+type Injector struct {
+	Config           a.Config
+	Config2          *b.Config
+	stringFirstName  string
+	stringFirstName2 string
+}
+
+// NewInjector builds each singleton once, in dependency order.
+func NewInjector() *Injector {
+	config := a.NewConfig()
+	config2 := b.NewConfig()
+	stringFirstName := NewFirstName()
+	stringFirstName2 := NewFirstNameUnderscore()
+	return &Injector{
+		Config:           config,
+		Config2:          config2,
+		stringFirstName:  stringFirstName,
+		stringFirstName2: stringFirstName2,
+	}
+}
+
+func InitConfig2() a.Config {
+	config := a.NewConfig()
+	return config
+}
+
+func InitConfig3() *b.Config {
+	config := b.NewConfig()
+	return config
+}
+
+func initstringFirstName() string {
+	stringFirstName := NewFirstName()
+	return stringFirstName
+}
+
+func initstringFirstName2() string {
+	stringFirstName := NewFirstNameUnderscore()
+	return stringFirstName
+}
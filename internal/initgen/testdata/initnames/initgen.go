@@ -0,0 +1,17 @@
+package initnames
+
+import (
+	"github.com/mgnsk/di-container/di"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/initnames/a"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/initnames/b"
+)
+
+// Generate registers the container for code generation.
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(a.NewConfig)
+		c.Register(b.NewConfig)
+		c.RegisterNamed("first-name", NewFirstName)
+		c.RegisterNamed("first_name", NewFirstNameUnderscore)
+	}, di.Initializers)
+}
@@ -0,0 +1,13 @@
+// Package initnames generates initializers whose names would conflict.
+package initnames
+
+// InitConfig is declared by the package.
+func InitConfig() {}
+
+func NewFirstName() string {
+	return "first"
+}
+
+func NewFirstNameUnderscore() string {
+	return "first_name"
+}
@@ -0,0 +1,123 @@
+// DO NOT EDIT. This code is generated by initgen.
+package injector
+
+import (
+	"context"
+)
+
+// Injector holds the singletons built by NewInjector.
+type Injector struct {
+	Config *Config
+	DB     *DB
+	Cache  *Cache
+	Server *Server
+	Store  Store
+}
+
+// NewInjector builds each singleton once, in dependency order.
+func NewInjector(ctx context.Context) (*Injector, func(), error) {
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, cleanup := NewDB(config)
+	cache, cleanup2, err := NewCache(db)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	server := NewServer(db, cache, func() (*Request, error) {
+		request := NewRequest(db)
+		return request, nil
+	})
+	return &Injector{
+		Config: config,
+		DB:     db,
+		Cache:  cache,
+		Server: server,
+		Store:  db,
+	}, func() {
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+func InitConfig(ctx context.Context) (*Config, error) {
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func InitDB(ctx context.Context) (*DB, func(), error) {
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, cleanup := NewDB(config)
+	return db, func() {
+		cleanup()
+	}, nil
+}
+
+func InitCache(ctx context.Context) (*Cache, func(), error) {
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, cleanup := NewDB(config)
+	cache, cleanup2, err := NewCache(db)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	return cache, func() {
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+func InitRequest(ctx context.Context) (*Request, func(), error) {
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, cleanup := NewDB(config)
+	request := NewRequest(db)
+	return request, func() {
+		cleanup()
+	}, nil
+}
+
+func InitServer(ctx context.Context) (*Server, func(), error) {
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, cleanup := NewDB(config)
+	cache, cleanup2, err := NewCache(db)
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+	server := NewServer(db, cache, func() (*Request, error) {
+		request := NewRequest(db)
+		return request, nil
+	})
+	return server, func() {
+		cleanup2()
+		cleanup()
+	}, nil
+}
+
+func InitStore(ctx context.Context) (Store, func(), error) {
+	config, err := NewConfig(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, cleanup := NewDB(config)
+	return db, func() {
+		cleanup()
+	}, nil
+}
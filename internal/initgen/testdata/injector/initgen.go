@@ -0,0 +1,15 @@
+package injector
+
+import "github.com/mgnsk/di-container/di"
+
+// Generate registers the container for code generation.
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewConfig)
+		c.Register(NewDB)
+		c.Bind((*Store)(nil), (**DB)(nil))
+		c.Register(NewCache)
+		c.Register(NewRequest, di.Transient)
+		c.Register(NewServer)
+	}, di.Initializers)
+}
@@ -0,0 +1,88 @@
+// Package injector builds a server with cleanups, a context and a transient factory.
+package injector
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mgnsk/di-container/di"
+)
+
+// Events records the provider calls and cleanups in order.
+var Events []string
+
+// FailCache makes NewCache fail.
+var FailCache bool
+
+type ctxKey struct{}
+
+// WithDSN returns a context with the DSN passed to NewConfig.
+func WithDSN(ctx context.Context, dsn string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, dsn)
+}
+
+type Config struct {
+	DSN string
+}
+
+func NewConfig(ctx context.Context) (*Config, error) {
+	dsn, ok := ctx.Value(ctxKey{}).(string)
+	if !ok {
+		return nil, errors.New("missing dsn")
+	}
+	Events = append(Events, "config")
+	return &Config{DSN: dsn}, nil
+}
+
+type Store interface {
+	Get() string
+}
+
+type DB struct {
+	dsn string
+}
+
+func (db *DB) Get() string {
+	return db.dsn
+}
+
+func NewDB(cfg *Config) (*DB, func()) {
+	Events = append(Events, "db")
+	return &DB{dsn: cfg.DSN}, func() {
+		Events = append(Events, "close db")
+	}
+}
+
+type Cache struct {
+	store Store
+}
+
+func NewCache(store Store) (*Cache, func(), error) {
+	if FailCache {
+		return nil, nil, errors.New("cache failed")
+	}
+	Events = append(Events, "cache")
+	return &Cache{store: store}, func() {
+		Events = append(Events, "close cache")
+	}, nil
+}
+
+type Request struct {
+	Store Store
+}
+
+func NewRequest(store Store) *Request {
+	Events = append(Events, "request")
+	return &Request{Store: store}
+}
+
+type Server struct {
+	DB         *DB
+	Cache      *Cache
+	NewRequest di.Provider[*Request]
+}
+
+func NewServer(db *DB, cache *Cache, newRequest di.Provider[*Request]) *Server {
+	Events = append(Events, "server")
+	return &Server{DB: db, Cache: cache, NewRequest: newRequest}
+}
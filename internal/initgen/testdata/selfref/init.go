@@ -0,0 +1,15 @@
+// DO NOT EDIT. This code is generated by initgen.
+package selfref
+
+// Injector holds the singletons built by NewInjector.
+type Injector struct {
+	Service *Service
+}
+
+// NewInjector builds each singleton once, in dependency order.
+func NewInjector() *Injector {
+	service := NewService()
+	return &Injector{
+		Service: service,
+	}
+}
@@ -0,0 +1,10 @@
+package selfref
+
+import "github.com/mgnsk/di-container/di"
+
+// Generate registers the container for code generation.
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(NewService)
+	})
+}
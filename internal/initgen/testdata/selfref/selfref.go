@@ -0,0 +1,15 @@
+// Package selfref uses its own generated injector.
+package selfref
+
+type Service struct {
+	Name string
+}
+
+func NewService() *Service {
+	return &Service{Name: "service"}
+}
+
+// Name returns the name of the service built by the injector.
+func Name() string {
+	return NewInjector().Service.Name
+}
@@ -0,0 +1,15 @@
+package a
+
+import "github.com/mgnsk/di-container/internal/initgen/testdata/shadow/config"
+
+func NewConfig() (config.Config, error) {
+	return config.Config{Addr: ":8080"}, nil
+}
+
+type Server struct {
+	Config config.Config
+}
+
+func NewServer(cfg config.Config) (*Server, error) {
+	return &Server{Config: cfg}, nil
+}
@@ -0,0 +1,49 @@
+// DO NOT EDIT. This code is generated by initgen.
+package shadow
+
+import (
+	"github.com/mgnsk/di-container/internal/initgen/testdata/shadow/a"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/shadow/config"
+)
+
+// Injector holds the singletons built by NewInjector.
+type Injector struct {
+	Config config.Config
+	Server *a.Server
+}
+
+// NewInjector builds each singleton once, in dependency order.
+func NewInjector() (*Injector, error) {
+	config2, err := a.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	server, err := a.NewServer(config2)
+	if err != nil {
+		return nil, err
+	}
+	return &Injector{
+		Config: config2,
+		Server: server,
+	}, nil
+}
+
+func InitConfig() (config.Config, error) {
+	config2, err := a.NewConfig()
+	if err != nil {
+		return config.Config{}, err
+	}
+	return config2, nil
+}
+
+func InitServer() (*a.Server, error) {
+	config2, err := a.NewConfig()
+	if err != nil {
+		return nil, err
+	}
+	server, err := a.NewServer(config2)
+	if err != nil {
+		return nil, err
+	}
+	return server, nil
+}
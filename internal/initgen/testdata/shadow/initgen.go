@@ -0,0 +1,15 @@
+// Package shadow registers providers of a package it does not import.
+package shadow
+
+import (
+	"github.com/mgnsk/di-container/di"
+	"github.com/mgnsk/di-container/internal/initgen/testdata/shadow/a"
+)
+
+// Generate registers the container for code generation.
+func Generate() {
+	di.Generate(func(c *di.Container) {
+		c.Register(a.NewConfig)
+		c.Register(a.NewServer)
+	}, di.Initializers)
+}
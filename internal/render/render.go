@@ -0,0 +1,266 @@
+// Package render writes dependency graphs as Graphviz DOT, Mermaid or JSON.
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Format is an output format of Write.
+type Format int
+
+const (
+	// DOT is the Graphviz DOT format.
+	DOT Format = iota
+	// Mermaid is a Mermaid flowchart.
+	Mermaid
+	// JSON is a JSON object of nodes and edges.
+	JSON
+)
+
+func (f Format) String() string {
+	switch f {
+	case DOT:
+		return "dot"
+	case Mermaid:
+		return "mermaid"
+	case JSON:
+		return "json"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// ParseFormat parses a format name: dot, mermaid or json.
+func ParseFormat(name string) (Format, error) {
+	for _, f := range []Format{DOT, Mermaid, JSON} {
+		if f.String() == name {
+			return f, nil
+		}
+	}
+	return 0, fmt.Errorf("container: unknown graph format '%s'", name)
+}
+
+// Singleton is the lifetime of nodes that are not marked.
+const Singleton = "singleton"
+
+// Node is a provided item.
+type Node struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Name     string `json:"name,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	Lifetime string `json:"lifetime"`
+	Group    string `json:"group,omitempty"`
+}
+
+// label of the node with the type, provider and marks on separate lines.
+func (n Node) label() []string {
+	lines := []string{n.Type}
+	if n.Name != "" {
+		lines[0] += fmt.Sprintf(" name=%q", n.Name)
+	}
+	if n.Provider != "" {
+		lines = append(lines, n.Provider)
+	}
+
+	var marks []string
+	if n.Lifetime != Singleton {
+		marks = append(marks, n.Lifetime)
+	}
+	if n.Group != "" {
+		marks = append(marks, "group: "+n.Group)
+	}
+	if len(marks) > 0 {
+		lines = append(lines, "("+strings.Join(marks, ", ")+")")
+	}
+
+	return lines
+}
+
+// Edge is an edge from an item to its dependency.
+type Edge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Optional bool   `json:"optional,omitempty"`
+	Factory  bool   `json:"factory,omitempty"`
+}
+
+// label of the edge.
+func (e Edge) label() string {
+	var marks []string
+	if e.Optional {
+		marks = append(marks, "optional")
+	}
+	if e.Factory {
+		marks = append(marks, "factory")
+	}
+	return strings.Join(marks, ", ")
+}
+
+// Graph is a dependency graph.
+type Graph struct {
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+}
+
+// Dep is a dependency of an item of type T.
+type Dep[T comparable] struct {
+	Item     T
+	Optional bool
+	Factory  bool
+}
+
+// Build creates the graph of the items in dependency order. node returns the
+// node of an item without its ID and deps the dependencies of an item.
+// Duplicate edges and dependencies that are not in items are left out.
+func Build[T comparable](items []T, node func(T) Node, deps func(T) []Dep[T]) Graph {
+	ids := make(map[T]string, len(items))
+	for i, item := range items {
+		ids[item] = fmt.Sprintf("n%d", i)
+	}
+
+	var g Graph
+	for _, item := range items {
+		n := node(item)
+		n.ID = ids[item]
+		g.Nodes = append(g.Nodes, n)
+
+		seen := make(map[Edge]bool)
+		for _, dep := range deps(item) {
+			e := Edge{From: ids[item], To: ids[dep.Item], Optional: dep.Optional, Factory: dep.Factory}
+			if e.To != "" && !seen[e] {
+				seen[e] = true
+				g.Edges = append(g.Edges, e)
+			}
+		}
+	}
+
+	return g
+}
+
+// Injector is the dependency graph of a generated injector.
+type Injector struct {
+	// Package is the import path of the package of the injector.
+	Package string `json:"package"`
+	// Name of the injector struct.
+	Name string `json:"name"`
+	Graph
+}
+
+// title of the injector graph.
+func (inj Injector) title() string {
+	return inj.Package + "." + inj.Name
+}
+
+// Write writes the graph to w in format.
+func Write(w io.Writer, g Graph, format Format) error {
+	switch format {
+	case DOT:
+		var b strings.Builder
+		b.WriteString("digraph container {\n")
+		b.WriteString("\tnode [shape=box];\n")
+		writeDOT(&b, g, "", "\t")
+		b.WriteString("}\n")
+		return writeString(w, b.String())
+	case Mermaid:
+		var b strings.Builder
+		b.WriteString("flowchart TD\n")
+		writeMermaid(&b, g, "", "\t")
+		return writeString(w, b.String())
+	case JSON:
+		return writeJSON(w, g)
+	default:
+		return fmt.Errorf("container: unknown graph format '%s'", format)
+	}
+}
+
+// WriteInjectors writes the graphs of the injectors to w in format as a single document.
+// Each injector is a subgraph in DOT and Mermaid and an object of a JSON array.
+func WriteInjectors(w io.Writer, injectors []Injector, format Format) error {
+	var b strings.Builder
+
+	switch format {
+	case DOT:
+		b.WriteString("digraph container {\n")
+		b.WriteString("\tnode [shape=box];\n")
+		for i, inj := range injectors {
+			fmt.Fprintf(&b, "\tsubgraph cluster_%d {\n", i)
+			fmt.Fprintf(&b, "\t\tlabel=\"%s\";\n", inj.title())
+			writeDOT(&b, inj.Graph, fmt.Sprintf("i%d_", i), "\t\t")
+			b.WriteString("\t}\n")
+		}
+		b.WriteString("}\n")
+	case Mermaid:
+		b.WriteString("flowchart TD\n")
+		for i, inj := range injectors {
+			fmt.Fprintf(&b, "\tsubgraph i%d[\"%s\"]\n", i, inj.title())
+			writeMermaid(&b, inj.Graph, fmt.Sprintf("i%d_", i), "\t\t")
+			b.WriteString("\tend\n")
+		}
+	case JSON:
+		if injectors == nil {
+			injectors = []Injector{}
+		}
+		return writeJSON(w, injectors)
+	default:
+		return fmt.Errorf("container: unknown graph format '%s'", format)
+	}
+
+	return writeString(w, b.String())
+}
+
+func writeString(w io.Writer, s string) error {
+	_, err := io.WriteString(w, s)
+	return err
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "\t")
+	return enc.Encode(v)
+}
+
+// writeDOT writes the nodes and edges of g with the node IDs prefixed.
+func writeDOT(b *strings.Builder, g Graph, prefix, indent string) {
+	for _, n := range g.Nodes {
+		lines := n.label()
+		for i := range lines {
+			lines[i] = strings.ReplaceAll(lines[i], `"`, `\"`)
+		}
+		attrs := fmt.Sprintf(`label="%s"`, strings.Join(lines, `\n`))
+		if n.Lifetime != Singleton {
+			attrs += " style=dashed"
+		}
+		fmt.Fprintf(b, "%s%s%s [%s];\n", indent, prefix, n.ID, attrs)
+	}
+
+	for _, e := range g.Edges {
+		if label := e.label(); label != "" {
+			fmt.Fprintf(b, "%s%s%s -> %s%s [label=\"%s\" style=dashed];\n", indent, prefix, e.From, prefix, e.To, label)
+		} else {
+			fmt.Fprintf(b, "%s%s%s -> %s%s;\n", indent, prefix, e.From, prefix, e.To)
+		}
+	}
+}
+
+// writeMermaid writes the nodes and edges of g with the node IDs prefixed.
+func writeMermaid(b *strings.Builder, g Graph, prefix, indent string) {
+	for _, n := range g.Nodes {
+		lines := n.label()
+		for i := range lines {
+			lines[i] = strings.ReplaceAll(lines[i], `"`, "#quot;")
+		}
+		fmt.Fprintf(b, "%s%s%s[\"%s\"]\n", indent, prefix, n.ID, strings.Join(lines, "<br/>"))
+	}
+
+	for _, e := range g.Edges {
+		if label := e.label(); label != "" {
+			fmt.Fprintf(b, "%s%s%s -.->|%s| %s%s\n", indent, prefix, e.From, label, prefix, e.To)
+		} else {
+			fmt.Fprintf(b, "%s%s%s --> %s%s\n", indent, prefix, e.From, prefix, e.To)
+		}
+	}
+}